@@ -48,58 +48,115 @@ func main() {
 		logger.Fatal("failed load config", zap.Error(err))
 	}
 
-	db, err := pgxpool.New(context.Background(), cfg.Database.DSN())
-	if err != nil {
-		logger.Fatal("failed connect to database", zap.Error(err))
-	}
-	defer db.Close()
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := db.Ping(ctx); err != nil {
-		logger.Fatal("failed ping database", zap.Error(err))
-	}
-
-	m, err := migrate.New("file://migrations", cfg.Database.DSN())
-	if err != nil {
-		logger.Fatal("failed initialize migration", zap.Error(err))
-	}
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		logger.Fatal("failed initialize migration", zap.Error(err))
+	var subRep repository.SubscriptionStore
+	var idempotencyRep repository.IdempotencyStore
+	var rateRep repository.ExchangeRateStore
+	var serviceRep repository.ServiceStore
+	var budgetRep repository.BudgetStore
+	switch cfg.Storage.Type {
+	case config.StorageMemory:
+		logger.Info("using in-memory storage")
+		subRep = repository.NewMemorySubscriptionRepository(logger)
+		idempotencyRep = repository.NewMemoryIdempotencyRepository()
+		rateRep = repository.NewMemoryExchangeRateRepository()
+		serviceRep = repository.NewMemoryServiceRepository()
+		budgetRep = repository.NewMemoryBudgetRepository()
+	case config.StoragePostgres, "": // без настройки — PostgreSQL
+		db := connectDatabase(cfg, logger)
+		defer db.Close()
+		subRep = repository.NewSubscriptionRepository(db, logger)
+		idempotencyRep = repository.NewIdempotencyRepository(db, logger)
+		rateRep = repository.NewExchangeRateRepository(db, logger)
+		serviceRep = repository.NewServiceRepository(db, logger)
+		budgetRep = repository.NewBudgetRepository(db, logger)
+	default:
+		logger.Fatal("unknown storage type, use postgres or memory", zap.String("type", cfg.Storage.Type))
 	}
 
-	logger.Info("migrations applied successfully")
-
-	subRep := repository.NewSubscriptionRepository(db, logger)
-	subService := service.NewSubscriptionService(subRep, logger)
+	budgetService := service.NewBudgetService(budgetRep, subRep, rateRep, logger)
+	subService := service.NewSubscriptionService(subRep, service.SubscriptionOptions{
+		Rates:    rateRep,
+		Services: serviceRep,
+		Budgets:  budgetService,
+		Rules: service.ValidationRules{
+			MaxPastYears:      cfg.Validation.MaxPastYears,
+			MaxFutureYears:    cfg.Validation.MaxFutureYears,
+			AllowUserIDChange: cfg.Validation.AllowUserIDChange,
+		},
+	}, logger)
 	subHandler := handlers.NewSubscriptionHandler(subService, logger)
+	budgetHandler := handlers.NewBudgetHandler(budgetService, logger)
+	rateHandler := handlers.NewExchangeRateHandler(service.NewExchangeRateService(rateRep, logger), logger)
+	catalogHandler := handlers.NewCatalogHandler(service.NewCatalogService(serviceRep, subRep, logger), logger)
+	idempotency := handlers.NewIdempotency(idempotencyRep, cfg.Idempotency.TTL, logger)
+	retentionHandler := handlers.NewRetentionHandler(subService, cfg.Retention.DeletedTTL, logger)
 
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
+	r.Use(handlers.AuditContext)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	r.Route("/subscriptions", func(r chi.Router) {
-		r.Post("/", subHandler.CreateSubscription)
-		r.Get("/", subHandler.ListSubscriptions)
-		r.Get("/total-cost", subHandler.GetTotalCost)
-		r.Get("/{id}", subHandler.GetSubscription)
-		r.Put("/{id}", subHandler.UpdateSubscription)
-		r.Delete("/{id}", subHandler.DeleteSubscription)
-	})
-
-	r.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("/swagger/doc.json"),
-	))
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "ok"}`))
+	// выгрузка может идти дольше общего таймаута, её прерывает только отключение клиента
+	r.Get("/subscriptions/export", subHandler.ExportSubscriptions)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.With(idempotency.Middleware).Post("/", subHandler.CreateSubscription)
+			r.With(idempotency.Middleware).Post("/batch", subHandler.BatchSubscriptions)
+			r.With(idempotency.ImportMiddleware).Post("/import", subHandler.ImportSubscriptions)
+			r.Get("/", subHandler.ListSubscriptions)
+			r.Get("/total-cost", subHandler.GetTotalCost)
+			r.Get("/cost-breakdown", subHandler.GetCostBreakdown)
+			r.Get("/{id}", subHandler.GetSubscription)
+			r.Put("/{id}", subHandler.UpdateSubscription)
+			r.Patch("/{id}", subHandler.PatchSubscription)
+			r.Delete("/{id}", subHandler.DeleteSubscription)
+			r.Post("/{id}/restore", subHandler.RestoreSubscription)
+			r.Get("/{id}/prices", subHandler.ListPrices)
+			r.Post("/{id}/prices", subHandler.AddPrice)
+			r.Delete("/{id}/prices/{effective_from}", subHandler.DeletePrice)
+			r.Get("/{id}/history", subHandler.GetHistory)
+		})
+
+		r.Route("/users/{user_id}", func(r chi.Router) {
+			r.Get("/renewals.ics", subHandler.RenewalsCalendar)
+			r.Get("/summary", subHandler.GetUserSummary)
+			r.Get("/budget", budgetHandler.GetBudget)
+			r.Put("/budget", budgetHandler.SaveBudget)
+			r.Delete("/budget", budgetHandler.DeleteBudget)
+			r.Get("/budget/alerts", budgetHandler.ListBudgetAlerts)
+		})
+
+		r.Route("/services", func(r chi.Router) {
+			r.Get("/", catalogHandler.ListServices)
+			r.Post("/", catalogHandler.CreateService)
+			r.Get("/{id}", catalogHandler.GetService)
+			r.Put("/{id}", catalogHandler.UpdateService)
+			r.Delete("/{id}", catalogHandler.DeleteService)
+		})
+
+		r.Post("/admin/subscriptions/purge", retentionHandler.PurgeSubscriptions)
+
+		r.Route("/admin/exchange-rates", func(r chi.Router) {
+			r.Get("/", rateHandler.ListExchangeRates)
+			r.Put("/", rateHandler.SaveExchangeRate)
+			r.Post("/import", rateHandler.ImportExchangeRates)
+			r.Delete("/{currency}/{effective_from}", rateHandler.DeleteExchangeRate)
+		})
+
+		r.Get("/swagger/*", httpSwagger.Handler(
+			httpSwagger.URL("/swagger/doc.json"),
+		))
+
+		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status": "ok"}`))
+		})
 	})
 
 	addr := fmt.Sprintf(":%s", os.ExpandEnv(cfg.App.Port))
@@ -124,7 +181,7 @@ func main() {
 
 	logger.Info("shutting down server...")
 
-	ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
@@ -133,3 +190,31 @@ func main() {
 
 	logger.Info("server stopped")
 }
+
+// connectDatabase подключается к PostgreSQL и применяет миграции.
+func connectDatabase(cfg *config.Config, logger *zap.Logger) *pgxpool.Pool {
+	db, err := pgxpool.New(context.Background(), cfg.Database.DSN())
+	if err != nil {
+		logger.Fatal("failed connect to database", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.Ping(ctx); err != nil {
+		logger.Fatal("failed ping database", zap.Error(err))
+	}
+
+	m, err := migrate.New("file://migrations", cfg.Database.DSN())
+	if err != nil {
+		logger.Fatal("failed initialize migration", zap.Error(err))
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		logger.Fatal("failed initialize migration", zap.Error(err))
+	}
+
+	logger.Info("migrations applied successfully")
+
+	return db
+}
@@ -0,0 +1,24 @@
+// Package audit передаёт сведения об источнике изменения от HTTP-слоя к хранилищам,
+// которые записывают их в журнал изменений подписок.
+package audit
+
+import "context"
+
+// Info кто и в каком запросе вносит изменение.
+type Info struct {
+	Actor     string // из заголовка X-Actor без проверки (аутентификации нет), пусто — не указан
+	RequestID string // X-Request-Id, назначенный middleware.RequestID
+}
+
+type contextKey struct{}
+
+// WithInfo добавляет сведения об изменении в контекст.
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext возвращает сведения об изменении; вне HTTP-запроса они пустые.
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(contextKey{}).(Info)
+	return info
+}
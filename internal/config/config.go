@@ -4,22 +4,37 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Поддерживаемые типы хранилища (storage.type)
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	Logging  LoggingConfig
+	App         AppConfig
+	Storage     StorageConfig
+	Database    DatabaseConfig
+	Logging     LoggingConfig
+	Validation  ValidationConfig
+	Idempotency IdempotencyConfig
+	Retention   RetentionConfig
 }
 
 type AppConfig struct {
 	Port string `mapstructure:"port"`
 }
 
+type StorageConfig struct {
+	Type string `mapstructure:"type"` // postgres (по умолчанию) или memory
+}
+
 type DatabaseConfig struct {
 	Host       string `mapstructure:"host"`
 	Port       string `mapstructure:"port"`
@@ -30,6 +45,21 @@ type DatabaseConfig struct {
 	MaxIdleCon int    `mapstructure:"max_idle_con"`
 }
 
+// правила проверки подписок перед сохранением
+type ValidationConfig struct {
+	MaxPastYears      int  `mapstructure:"max_past_years"`
+	MaxFutureYears    int  `mapstructure:"max_future_years"`
+	AllowUserIDChange bool `mapstructure:"allow_user_id_change"`
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration `mapstructure:"ttl"` // сколько хранится ответ на запрос с Idempotency-Key
+}
+
+type RetentionConfig struct {
+	DeletedTTL time.Duration `mapstructure:"deleted_ttl"` // сколько хранится удалённая подписка до окончательной очистки
+}
+
 type LoggingConfig struct {
 	Level       string `mapstructure:"level"`
 	Development bool   `mapstructure:"development"`
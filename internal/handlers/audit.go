@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"em-internship/internal/audit"
+)
+
+const (
+	actorHeader  = "X-Actor"
+	maxActorSize = 255
+	// maxRequestIDSize размер subscription_events.request_id: middleware.RequestID берёт ID клиента как есть
+	maxRequestIDSize = 255
+)
+
+// AuditContext передаёт хранилищам автора изменения из заголовка X-Actor и ID запроса
+// для журнала изменений. Ставится после middleware.RequestID.
+// Аутентификации в сервисе нет, поэтому X-Actor не проверяется: клиент может указать любого автора,
+// и журнал нельзя считать доказательством того, кто внёс изменение.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(actorHeader))
+		if len(actor) > maxActorSize {
+			writeProblem(w, r, http.StatusBadRequest, actorHeader+" must be at most "+strconv.Itoa(maxActorSize)+" characters long")
+			return
+		}
+		requestID := middleware.GetReqID(r.Context())
+		if len(requestID) > maxRequestIDSize {
+			writeProblem(w, r, http.StatusBadRequest, middleware.RequestIDHeader+" must be at most "+strconv.Itoa(maxRequestIDSize)+" characters long")
+			return
+		}
+
+		ctx := audit.WithInfo(r.Context(), audit.Info{
+			Actor:     actor,
+			RequestID: requestID,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"em-internship/internal/audit"
+)
+
+func TestAuditContext(t *testing.T) {
+	var got audit.Info
+	handler := middleware.RequestID(AuditContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = audit.FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	do := func(requestID, actor string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPatch, "/subscriptions/1", nil)
+		r.Header.Set(middleware.RequestIDHeader, requestID)
+		r.Header.Set(actorHeader, actor)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("req-1", " admin@example.com "); w.Code != http.StatusNoContent || got.RequestID != "req-1" || got.Actor != "admin@example.com" {
+		t.Errorf("status %d, audit info %+v", w.Code, got)
+	}
+
+	// длинный ID не дошёл бы до журнала: колонка request_id ограничена 255 символами
+	got = audit.Info{}
+	if w := do(strings.Repeat("r", maxRequestIDSize+1), ""); w.Code != http.StatusBadRequest || got != (audit.Info{}) {
+		t.Errorf("oversized request ID: status %d, audit info %+v", w.Code, got)
+	}
+	if w := do("req-2", strings.Repeat("a", maxActorSize+1)); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized actor: status %d, want 400", w.Code)
+	}
+}
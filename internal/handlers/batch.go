@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+// maxBatchBodySize такой же, как у запросов с Idempotency-Key, чтобы ключ не менял границу
+const maxBatchBodySize = maxIdempotentBodySize
+
+// статусы успешных операций пакета — как у отдельных запросов
+var batchSuccessStatus = map[string]int{
+	models.BatchOpCreate: http.StatusCreated,
+	models.BatchOpUpdate: http.StatusOK,
+	models.BatchOpDelete: http.StatusNoContent,
+}
+
+// BatchSubscriptions godoc
+// @Summary Create, update and delete subscriptions in one request
+// @Description Apply operations in order within one transaction. Each operation is validated like the single request: create takes the body of POST /subscriptions, update takes the body of PUT /subscriptions/{id} and deletes are soft; version works like If-Match. In atomic mode (default) the first failed operation rolls back the whole batch: the response has the status of that operation and the other operations get 424. In partial mode a failed operation rolls back only itself and the response is 200. Each item has the status and error the single request would return
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body models.BatchRequest true "Operations, at most 1000"
+// @Param Idempotency-Key header string false "Unique key of the request; a retry with the same key and body replays the stored response instead of applying the batch again"
+// @Success 200 {object} models.BatchResult
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/batch [post]
+func (h *SubscriptionHandler) BatchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize)).Decode(&req); err != nil {
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	outcome, err := h.service.Batch(r.Context(), req)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	status := http.StatusOK
+	result := models.BatchResult{Mode: outcome.Mode, Items: make([]models.BatchItemResult, len(outcome.Items))}
+	for i, item := range outcome.Items {
+		op := req.Operations[i]
+		res := models.BatchItemResult{Index: i, Op: op.Op, ID: op.ID, Status: batchSuccessStatus[op.Op]}
+		if item.Err != nil {
+			problem := errorProblem(r, h.logger, item.Err)
+			res.Status = problem.Status
+			res.Error = &problem
+			result.Failed++
+			// пакет целиком получает статус операции, из-за которой он откатился
+			if outcome.Mode == models.BatchModeAtomic && !errors.Is(item.Err, service.ErrBatchAborted) {
+				status = problem.Status
+			}
+		} else {
+			res.Subscription = item.Subscription
+			if item.Subscription != nil {
+				res.ID = item.Subscription.ID
+			}
+			result.Succeeded++
+		}
+		result.Items[i] = res
+	}
+
+	writeJSON(w, status, result)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/repository"
+	"em-internship/internal/service"
+)
+
+func TestBatchSubscriptions_BodyLimit(t *testing.T) {
+	svc := service.NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), service.SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+	h := NewSubscriptionHandler(svc, zap.NewNop())
+
+	// без Idempotency-Key тело ограничено так же, как с ним
+	body := `{"operations": [], "padding": "` + strings.Repeat("x", maxBatchBodySize) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/subscriptions/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	h.BatchSubscriptions(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch: status %d, want 400", w.Code)
+	}
+}
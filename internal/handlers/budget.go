@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+// BudgetService бюджеты пользователей, которые используют обработчики.
+type BudgetService interface {
+	Get(ctx context.Context, userID string) (*models.Budget, error)
+	Save(ctx context.Context, userID string, input models.BudgetInput) (*models.Budget, error)
+	Delete(ctx context.Context, userID string) error
+	ListAlerts(ctx context.Context, userID string) (*models.BudgetAlertList, error)
+}
+
+var _ BudgetService = (*service.BudgetService)(nil)
+
+type BudgetHandler struct {
+	service BudgetService
+	logger  *zap.Logger
+}
+
+func NewBudgetHandler(service BudgetService, logger *zap.Logger) *BudgetHandler {
+	return &BudgetHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetBudget godoc
+// @Summary Get the monthly budget of a user
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [get]
+func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+
+	budget, err := h.service.Get(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, budget)
+}
+
+// SaveBudget godoc
+// @Summary Create or replace the monthly budget of a user
+// @Description Set the amount the user's subscriptions may cost per month. Spend is the total-cost of the current month with amortize=true in the budget currency; it is checked after every change of the user's subscriptions and right after saving the budget. When spend crosses the budget an alert is recorded and, if webhook_url is set, sent there as a POST with the alert as JSON body. webhook_url must resolve to public addresses only (private, loopback and link-local targets are rejected with 422); redirects are not followed
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param budget body models.BudgetInput true "Budget"
+// @Success 200 {object} models.Budget
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [put]
+func (h *BudgetHandler) SaveBudget(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+	var input models.BudgetInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	input.Currency = strings.ToUpper(input.Currency)
+
+	budget, err := h.service.Save(r.Context(), userID, input)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, budget)
+}
+
+// DeleteBudget godoc
+// @Summary Delete the monthly budget of a user
+// @Description Stop checking the budget; recorded alerts are kept
+// @Tags budgets
+// @Param user_id path string true "User ID"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget [delete]
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+
+	if err := h.service.Delete(r.Context(), userID); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListBudgetAlerts godoc
+// @Summary List budget alerts of a user
+// @Description Alerts recorded when the monthly spend crossed the budget, newest first
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} models.BudgetAlertList
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/budget/alerts [get]
+func (h *BudgetHandler) ListBudgetAlerts(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+
+	list, err := h.service.ListAlerts(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
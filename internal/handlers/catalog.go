@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+// CatalogService каталог сервисов, который используют обработчики.
+type CatalogService interface {
+	List(ctx context.Context, filter models.ServiceFilter) (*models.ServiceList, error)
+	GetByID(ctx context.Context, id string) (*models.Service, error)
+	Create(ctx context.Context, input models.ServiceInput) (*models.Service, error)
+	Update(ctx context.Context, id string, input models.ServiceInput) (*models.Service, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var _ CatalogService = (*service.CatalogService)(nil)
+
+type CatalogHandler struct {
+	service CatalogService
+	logger  *zap.Logger
+}
+
+func NewCatalogHandler(service CatalogService, logger *zap.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListServices godoc
+// @Summary List catalog services
+// @Description List services of the catalog ordered by name
+// @Tags services
+// @Produce json
+// @Param category query string false "Category filter (exact match)"
+// @Param q query string false "Prefix of the name or an alias (case-insensitive)"
+// @Success 200 {object} models.ServiceList
+// @Failure 500 {object} models.Problem
+// @Router /services [get]
+func (h *CatalogHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.ServiceFilter{
+		Category: query.Get("category"),
+		Query:    query.Get("q"),
+	}
+
+	list, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// CreateService godoc
+// @Summary Create a catalog service
+// @Description Add a service to the catalog. Name and aliases must not match a name or alias of another service, ignoring case and extra spaces. Existing subscriptions without a service whose service_name matches are linked to the new service
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body models.ServiceInput true "Service data"
+// @Success 201 {object} models.Service
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services [post]
+func (h *CatalogHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	input, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	svc, err := h.service.Create(r.Context(), input)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, svc)
+}
+
+// GetService godoc
+// @Summary Get a catalog service
+// @Tags services
+// @Produce json
+// @Param id path string true "Service ID"
+// @Success 200 {object} models.Service
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [get]
+func (h *CatalogHandler) GetService(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	svc, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, svc)
+}
+
+// UpdateService godoc
+// @Summary Replace a catalog service
+// @Description Fully replace a service; linked subscriptions take the new name, subscriptions without a service matching the new name or aliases are linked to it
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "Service ID"
+// @Param service body models.ServiceInput true "Service data"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [put]
+func (h *CatalogHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	input, ok := h.decodeInput(w, r)
+	if !ok {
+		return
+	}
+
+	svc, err := h.service.Update(r.Context(), id, input)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, svc)
+}
+
+// DeleteService godoc
+// @Summary Delete a catalog service
+// @Description Delete a service that no subscription references
+// @Tags services
+// @Param id path string true "Service ID"
+// @Success 204
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /services/{id} [delete]
+func (h *CatalogHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeInput читает тело сервиса; при ошибке ответ уже отправлен.
+func (h *CatalogHandler) decodeInput(w http.ResponseWriter, r *http.Request) (models.ServiceInput, bool) {
+	var input models.ServiceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return input, false
+	}
+	input.Currency = strings.ToUpper(input.Currency)
+	return input, true
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/service"
+)
+
+const problemContentType = "application/problem+json"
+
+// writeJSON отдаёт v в JSON с указанным статусом.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeProblem отдаёт ошибку в формате RFC 7807.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, violations ...models.FieldViolation) {
+	sendProblem(w, newProblem(r, status, detail, violations...))
+}
+
+func newProblem(r *http.Request, status int, detail string, violations ...models.FieldViolation) models.Problem {
+	return models.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    violations,
+	}
+}
+
+func sendProblem(w http.ResponseWriter, problem models.Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// writeError центральное сопоставление ошибок сервиса и хранилища с HTTP-статусами:
+// не найдено — 404, неверные параметры запроса — 400, нарушение правил валидации или нет курса валюты — 422,
+// конфликт уникальности или восстановление неудалённой подписки — 409, устаревший If-Match — 412,
+// операция отменённого пакета — 424, остальное — 500 без деталей для клиента.
+// Ошибка логируется только здесь: обработчики её не логируют.
+func writeError(w http.ResponseWriter, r *http.Request, logger *zap.Logger, err error) {
+	problem := errorProblem(r, logger, err)
+	if problem.Status < http.StatusInternalServerError {
+		// ошибки сервера уже залогировал errorProblem
+		logger.Warn("request failed", zap.Int("status", problem.Status), zap.String("path", r.URL.Path),
+			zap.String("request_id", problem.RequestID), zap.Error(err))
+	}
+	sendProblem(w, problem)
+}
+
+// errorProblem ошибка в формате RFC 7807 по правилам writeError; нужна, когда ошибка
+// отдаётся внутри ответа, например для операции пакета.
+func errorProblem(r *http.Request, logger *zap.Logger, err error) models.Problem {
+	var validationErrs validator.ValidationErrors
+	var pgErr *pgconn.PgError
+	var rowErr *service.RowError
+
+	switch {
+	case errors.As(err, &rowErr):
+		// ошибка строки файла или пакета сопоставляется как обычная, к описанию добавляется номер строки
+		problem := errorProblem(r, logger, rowErr.Err)
+		problem.Detail = fmt.Sprintf("%s at row %d", problem.Detail, rowErr.Row)
+		return problem
+	case errors.Is(err, repository.ErrSubscriptionNotFound):
+		return newProblem(r, http.StatusNotFound, "subscription not found")
+	case errors.Is(err, repository.ErrSubscriptionNotDeleted):
+		return newProblem(r, http.StatusConflict, repository.ErrSubscriptionNotDeleted.Error())
+	case errors.Is(err, repository.ErrPriceChangeNotFound):
+		return newProblem(r, http.StatusNotFound, "price change not found")
+	case errors.Is(err, repository.ErrPriceChangeExists):
+		return newProblem(r, http.StatusConflict, repository.ErrPriceChangeExists.Error())
+	case errors.Is(err, service.ErrPriceEditNotAllowed):
+		return newProblem(r, http.StatusConflict, service.ErrPriceEditNotAllowed.Error())
+	case errors.Is(err, service.ErrPriceChangeOutsidePeriod):
+		return newProblem(r, http.StatusConflict, err.Error())
+	case errors.Is(err, repository.ErrIdempotencyKeyBusy):
+		return newProblem(r, http.StatusConflict, repository.ErrIdempotencyKeyBusy.Error())
+	case errors.Is(err, repository.ErrServiceNotFound):
+		return newProblem(r, http.StatusNotFound, "service not found")
+	case errors.Is(err, repository.ErrServiceNameTaken), errors.Is(err, repository.ErrServiceInUse):
+		return newProblem(r, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrUnknownService):
+		return newProblem(r, http.StatusUnprocessableEntity, service.ErrUnknownService.Error())
+	case errors.Is(err, service.ErrWebhookNotAllowed):
+		return newProblem(r, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrBudgetNotFound):
+		return newProblem(r, http.StatusNotFound, "budget not found")
+	case errors.Is(err, repository.ErrExchangeRateNotFound):
+		return newProblem(r, http.StatusNotFound, "exchange rate not found")
+	case errors.Is(err, repository.ErrMissingExchangeRate):
+		// без курса сумму нельзя посчитать, пока курс не будет добавлен
+		return newProblem(r, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrInvalidImportValue):
+		return newProblem(r, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, repository.ErrAmountOverflow):
+		return newProblem(r, http.StatusUnprocessableEntity, "total amount is too large, narrow the period or filters")
+	case errors.Is(err, service.ErrBaseCurrencyRate):
+		return newProblem(r, http.StatusUnprocessableEntity, service.ErrBaseCurrencyRate.Error())
+	case errors.Is(err, service.ErrBatchAborted):
+		return newProblem(r, http.StatusFailedDependency, service.ErrBatchAborted.Error())
+	case errors.Is(err, repository.ErrVersionMismatch), errors.Is(err, errPreconditionFailed):
+		return newProblem(r, http.StatusPreconditionFailed, "subscription has been modified, fetch it again and retry with the new ETag")
+	case errors.Is(err, service.ErrInvalidQuery):
+		violations := fieldViolations(err)
+		detail := err.Error()
+		if len(violations) > 0 {
+			detail = service.ErrInvalidQuery.Error()
+		}
+		return newProblem(r, http.StatusBadRequest, detail, violations...)
+	case errors.Is(err, service.ErrInvalidDateFormat):
+		return newProblem(r, http.StatusBadRequest, service.ErrInvalidDateFormat.Error())
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return newProblem(r, http.StatusBadRequest, repository.ErrInvalidCursor.Error())
+	case errors.Is(err, service.ErrInvalidPatch), errors.Is(err, service.ErrInvalidBatchOperation):
+		return newProblem(r, http.StatusBadRequest, err.Error())
+	case errors.As(err, &validationErrs):
+		return newProblem(r, http.StatusUnprocessableEntity, "validation failed", fieldViolations(err)...)
+	case errors.As(err, &pgErr):
+		return dbProblem(r, logger, pgErr)
+	default:
+		logger.Error("internal error", zap.Error(err), zap.String("path", r.URL.Path), zap.String("request_id", middleware.GetReqID(r.Context())))
+		return newProblem(r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// dbProblem сопоставляет коды ошибок PostgreSQL (SQLSTATE) со статусами.
+func dbProblem(r *http.Request, logger *zap.Logger, pgErr *pgconn.PgError) models.Problem {
+	switch {
+	case pgErr.Code == "23505": // unique_violation
+		return newProblem(r, http.StatusConflict, "resource already exists")
+	case pgErr.Code == "23503": // foreign_key_violation
+		return newProblem(r, http.StatusConflict, "referenced resource does not exist or is still in use")
+	case pgErr.Code == "23502", pgErr.Code == "23514": // not_null_violation, check_violation
+		return newProblem(r, http.StatusUnprocessableEntity, "constraint violation: "+pgErr.ConstraintName)
+	case strings.HasPrefix(pgErr.Code, "22"): // data_exception
+		return newProblem(r, http.StatusBadRequest, "invalid value")
+	default:
+		logger.Error("database error", zap.Error(pgErr), zap.String("code", pgErr.Code), zap.String("path", r.URL.Path), zap.String("request_id", middleware.GetReqID(r.Context())))
+		return newProblem(r, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// fieldViolations переводит ошибки валидатора в список нарушений по полям.
+func fieldViolations(err error) []models.FieldViolation {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	violations := make([]models.FieldViolation, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		violations = append(violations, models.FieldViolation{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: violationMessage(fe),
+		})
+	}
+	return violations
+}
+
+func violationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_unless", "required_without":
+		return "is required"
+	case "uuid":
+		return "must be a valid UUID"
+	case "month_year":
+		return "must be in MM-YYYY format"
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "year_range":
+		return fmt.Sprintf("year must be within %s", fe.Param())
+	case "not_before_start":
+		return fmt.Sprintf("must not be earlier than %s", fe.Param())
+	case "immutable":
+		return "cannot be changed"
+	case "after_start":
+		return fmt.Sprintf("must be later than %s", fe.Param())
+	case "not_after_end":
+		return fmt.Sprintf("must not be later than %s", fe.Param())
+	case "url":
+		return "must be a valid URL"
+	case "currency":
+		return "must be an ISO 4217 code of a currency with two decimal places"
+	case "positive_decimal":
+		return "must be a positive decimal number, e.g. \"92.5\""
+	default:
+		return fmt.Sprintf("failed on %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/service"
+)
+
+func TestWriteError(t *testing.T) {
+	validationErr := func() error {
+		svc := service.NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), service.SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+		_, err := svc.Create(t.Context(), models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 100, StartDate: "13-2025"})
+		return err
+	}()
+
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantViolations int
+	}{
+		{"not found", fmt.Errorf("get: %w", repository.ErrSubscriptionNotFound), http.StatusNotFound, 0},
+		{"not deleted", repository.ErrSubscriptionNotDeleted, http.StatusConflict, 0},
+		{"invalid date", service.ErrInvalidDateFormat, http.StatusBadRequest, 0},
+		{"invalid query", fmt.Errorf("%w: %w", service.ErrInvalidQuery, repository.ErrInvalidCursor), http.StatusBadRequest, 0},
+		{"validation", validationErr, http.StatusUnprocessableEntity, 2},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, http.StatusConflict, 0},
+		{"check violation", &pgconn.PgError{Code: "23514"}, http.StatusUnprocessableEntity, 0},
+		{"unknown", errors.New("connection refused"), http.StatusInternalServerError, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+
+			writeError(w, r, zap.NewNop(), tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problemContentType {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			var problem models.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Status != tt.wantStatus || problem.Instance != "/subscriptions" {
+				t.Errorf("problem = %+v", problem)
+			}
+			if len(problem.Errors) != tt.wantViolations {
+				t.Errorf("violations = %+v, want %d", problem.Errors, tt.wantViolations)
+			}
+		})
+	}
+}
+
+func TestWriteError_RowError(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/admin/exchange-rates/import", nil)
+
+	writeError(w, r, zap.NewNop(), &service.RowError{Row: 2, Err: service.ErrBaseCurrencyRate})
+
+	var problem models.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusUnprocessableEntity || problem.Detail != service.ErrBaseCurrencyRate.Error()+" at row 2" {
+		t.Errorf("problem = %d %+v", w.Code, problem)
+	}
+}
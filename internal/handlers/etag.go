@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"em-internship/internal/models"
+)
+
+var errPreconditionFailed = errors.New("If-Match does not match current version")
+
+// setETag выставляет ETag по версии подписки.
+func setETag(w http.ResponseWriter, sub *models.Subscription) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(sub.Version)))
+}
+
+// parseIfMatch возвращает версию из If-Match; 0 — заголовок не передан или равен "*".
+// Слабые и нечисловые ETag никогда не совпадают с версией подписки, поэтому дают 412.
+func parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	tag, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, errPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, errPreconditionFailed
+	}
+	return version, nil
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+// maxImportBodySize ограничение на размер импортируемого файла
+const maxImportBodySize = 10 << 20
+
+// ExchangeRateService управление курсами валют, которое используют обработчики.
+type ExchangeRateService interface {
+	List(ctx context.Context, currency string) (*models.ExchangeRateList, error)
+	Save(ctx context.Context, input models.ExchangeRateInput) error
+	Import(ctx context.Context, rates []models.ExchangeRateInput) (*models.ExchangeRateImportResult, error)
+	Delete(ctx context.Context, currency, effectiveFrom string) error
+}
+
+var _ ExchangeRateService = (*service.ExchangeRateService)(nil)
+
+type ExchangeRateHandler struct {
+	service ExchangeRateService
+	logger  *zap.Logger
+}
+
+func NewExchangeRateHandler(service ExchangeRateService, logger *zap.Logger) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListExchangeRates godoc
+// @Summary List exchange rates
+// @Description List exchange rates to RUB ordered by currency and effective month
+// @Tags exchange-rates
+// @Produce json
+// @Param currency query string false "ISO 4217 currency filter"
+// @Success 200 {object} models.ExchangeRateList
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /admin/exchange-rates [get]
+func (h *ExchangeRateHandler) ListExchangeRates(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.List(r.Context(), strings.ToUpper(r.URL.Query().Get("currency")))
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// SaveExchangeRate godoc
+// @Summary Create or replace an exchange rate
+// @Description Set the rate of a currency to RUB starting from a month; the rate applies until the next rate of the same currency
+// @Tags exchange-rates
+// @Accept json
+// @Param rate body models.ExchangeRateInput true "Exchange rate"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /admin/exchange-rates [put]
+func (h *ExchangeRateHandler) SaveExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var input models.ExchangeRateInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	input.Currency = strings.ToUpper(input.Currency)
+
+	if err := h.service.Save(r.Context(), input); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportExchangeRates godoc
+// @Summary Import exchange rates from CSV
+// @Description Create or replace exchange rates from a CSV file with the header currency,effective_from,rate. The import is atomic: if any row is invalid nothing is saved
+// @Tags exchange-rates
+// @Accept text/csv
+// @Produce json
+// @Param file body string true "CSV with columns currency,effective_from,rate"
+// @Success 200 {object} models.ExchangeRateImportResult
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /admin/exchange-rates/import [post]
+func (h *ExchangeRateHandler) ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := readExchangeRatesCSV(http.MaxBytesReader(w, r.Body, maxImportBodySize))
+	if err != nil {
+		h.logger.Warn("failed to read exchange rates file", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.service.Import(r.Context(), rates)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete an exchange rate
+// @Tags exchange-rates
+// @Param currency path string true "ISO 4217 currency"
+// @Param effective_from path string true "Month the rate is effective from (MM-YYYY)"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /admin/exchange-rates/{currency}/{effective_from} [delete]
+func (h *ExchangeRateHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	currency := strings.ToUpper(r.PathValue("currency"))
+	effectiveFrom := r.PathValue("effective_from")
+
+	if err := h.service.Delete(r.Context(), currency, effectiveFrom); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readExchangeRatesCSV читает CSV с заголовком currency,effective_from,rate (порядок колонок любой).
+func readExchangeRatesCSV(body io.Reader) ([]models.ExchangeRateInput, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"currency", "effective_from", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("missing column " + name)
+		}
+	}
+
+	var rates []models.ExchangeRateInput
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, models.ExchangeRateInput{
+			Currency:      strings.ToUpper(record[columns["currency"]]),
+			EffectiveFrom: record[columns["effective_from"]],
+			Rate:          record[columns["rate"]],
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+const (
+	idempotencyKeyHeader  = "Idempotency-Key"
+	maxIdempotencyKeySize = 255
+	maxIdempotentBodySize = 1 << 20
+	defaultIdempotencyTTL = 24 * time.Hour
+)
+
+// заголовки ответа, которые сохраняются и повторяются вместе с телом
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency повторяет сохранённый ответ для запросов с уже использованным заголовком Idempotency-Key.
+// Тот же ключ с другим телом запроса даёт 409, ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+type Idempotency struct {
+	store  repository.IdempotencyStore
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func NewIdempotency(store repository.IdempotencyStore, ttl time.Duration, logger *zap.Logger) *Idempotency {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	return &Idempotency{
+		store:  store,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Middleware оборачивает обработчик; запросы без Idempotency-Key проходят как есть.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return i.middleware(next, maxIdempotentBodySize)
+}
+
+// ImportMiddleware то же для загрузки файлов: тело ограничено, как у импорта.
+func (i *Idempotency) ImportMiddleware(next http.Handler) http.Handler {
+	return i.middleware(next, maxImportBodySize)
+}
+
+func (i *Idempotency) middleware(next http.Handler, maxBodySize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeySize {
+			writeProblem(w, r, http.StatusBadRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeySize)+" characters long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(r, body)
+		existing, err := i.store.Reserve(r.Context(), key, hash, time.Now().Add(i.ttl))
+		if err != nil {
+			writeError(w, r, i.logger, err)
+			return
+		}
+
+		if existing != nil {
+			i.replay(w, r, existing, hash)
+			return
+		}
+
+		// клиент мог уже отключиться, но результат нужно сохранить для повторов
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			// ответ 5xx, паника обработчика или ошибка сохранения: ключ освобождается, чтобы запрос
+			// можно было повторить, а не получать 409 до истечения TTL. Паника идёт дальше, к Recoverer
+			if completed {
+				return
+			}
+			if err := i.store.Release(ctx, key); err != nil {
+				i.logger.Error("failed to release idempotency key", zap.Error(err))
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		record := models.IdempotencyRecord{
+			Key:         key,
+			RequestHash: hash,
+			StatusCode:  rec.status,
+			Headers:     make(map[string]string),
+			Body:        rec.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		if err := i.store.Complete(ctx, record); err != nil {
+			i.logger.Error("failed to save idempotent response", zap.Error(err))
+			return
+		}
+		completed = true
+	})
+}
+
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, record *models.IdempotencyRecord, hash string) {
+	switch {
+	case record.RequestHash != hash:
+		writeProblem(w, r, http.StatusConflict, "Idempotency-Key has already been used with a different request")
+	case record.StatusCode == 0:
+		writeProblem(w, r, http.StatusConflict, "request with this Idempotency-Key is still being processed")
+	default:
+		for name, value := range record.Headers {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(record.StatusCode)
+		w.Write(record.Body)
+	}
+}
+
+// requestHash отпечаток запроса: ключ нельзя переиспользовать для другого метода, пути или тела.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	// параметры запроса тоже входят в хэш: импорт с dry_run=true и без него — разные запросы
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder пропускает ответ клиенту и параллельно запоминает статус и тело.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
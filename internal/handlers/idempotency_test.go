@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
+	"em-internship/internal/repository"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"1"`)
+		writeJSON(w, status, map[string]int{"call": calls})
+	})
+	handler := NewIdempotency(repository.NewMemoryIdempotencyRepository(), time.Hour, zap.NewNop()).Middleware(next)
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := do("key-1", `{"price": 400}`)
+	if first.Code != http.StatusCreated || calls != 1 {
+		t.Fatalf("first request: status %d, calls %d", first.Code, calls)
+	}
+
+	replayed := do("key-1", `{"price": 400}`)
+	if calls != 1 {
+		t.Errorf("retry must not call the handler again, calls = %d", calls)
+	}
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != first.Body.String() {
+		t.Errorf("replayed response = %d %s, want %d %s", replayed.Code, replayed.Body, first.Code, first.Body)
+	}
+	if replayed.Header().Get("ETag") != `"1"` || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("replayed headers = %v", replayed.Header())
+	}
+
+	if conflict := do("key-1", `{"price": 500}`); conflict.Code != http.StatusConflict {
+		t.Errorf("same key with another body: status %d, want 409", conflict.Code)
+	}
+
+	do("", `{"price": 400}`)
+	do("", `{"price": 400}`)
+	if calls != 3 {
+		t.Errorf("requests without key must always reach the handler, calls = %d", calls)
+	}
+
+	// ответ 5xx не сохраняется, повтор снова выполняет запрос
+	status = http.StatusInternalServerError
+	do("key-2", `{}`)
+	status = http.StatusCreated
+	if retry := do("key-2", `{}`); retry.Code != http.StatusCreated || calls != 5 {
+		t.Errorf("retry after 5xx: status %d, calls %d", retry.Code, calls)
+	}
+}
+
+func TestIdempotencyMiddlewareReleasesKeyOnPanic(t *testing.T) {
+	panics := true
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("handler failed")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	handler := middleware.Recoverer(NewIdempotency(repository.NewMemoryIdempotencyRepository(), time.Hour, zap.NewNop()).Middleware(next))
+
+	do := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{}`))
+		r.Header.Set(idempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if status := do(); status != http.StatusInternalServerError {
+		t.Fatalf("panicking handler: status %d, want 500", status)
+	}
+	panics = false
+	if status := do(); status != http.StatusCreated {
+		t.Errorf("retry after panic: status %d, want 201", status)
+	}
+}
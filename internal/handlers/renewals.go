@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+	"em-internship/internal/validation"
+)
+
+// icalLineLimit длина строки iCalendar в октетах без CRLF (RFC 5545, 3.1)
+const icalLineLimit = 75
+
+// RenewalsCalendar godoc
+// @Summary Calendar of subscription renewals
+// @Description iCalendar feed (RFC 5545) with one recurring all-day event per subscription of the user that is not deleted and has not ended. Events start on the first day of start_date and repeat by billing_period and billing_interval until the last day of end_date. The summary contains the service name and the price of the current month (or of start_date for subscriptions that have not started yet)
+// @Tags subscriptions
+// @Produce text/calendar
+// @Param user_id path string true "User ID"
+// @Success 200 {string} string "iCalendar"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/renewals.ics [get]
+func (h *SubscriptionHandler) RenewalsCalendar(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+
+	renewals, err := h.service.Renewals(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	calendar, err := renewalsCalendar(renewals)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="renewals.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(calendar))
+}
+
+// renewalsCalendar VCALENDAR с повторяющимся событием на каждую подписку.
+func renewalsCalendar(renewals []service.Renewal) (string, error) {
+	var b strings.Builder
+	line := func(s string) {
+		b.WriteString(foldICalLine(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//em-internship//Subscription renewals//RU")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:Продления подписок")
+	for _, renewal := range renewals {
+		sub := renewal.Subscription
+		start, err := validation.ParseMonthYear(sub.StartDate)
+		if err != nil {
+			return "", err
+		}
+		rrule := renewalRule(sub.BillingPeriod, sub.BillingInterval)
+		if sub.EndDate != nil {
+			end, err := validation.ParseMonthYear(*sub.EndDate)
+			if err != nil {
+				return "", err
+			}
+			// подписка активна до конца месяца end_date
+			rrule += ";UNTIL=" + end.AddDate(0, 1, -1).Format("20060102")
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:" + sub.ID + "@em-internship")
+		line("DTSTAMP:" + sub.UpdatedAt.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		line("RRULE:" + rrule)
+		line("SUMMARY:" + escapeICalText(fmt.Sprintf("%s — %s %s", sub.ServiceName, renewal.Price, sub.Currency)))
+		if sub.Category != "" {
+			line("CATEGORIES:" + escapeICalText(sub.Category))
+		}
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String(), nil
+}
+
+// renewalRule RRULE без UNTIL для периода оплаты, как при расчёте стоимости:
+// недельные списания считаются от первого числа месяца start_date.
+func renewalRule(period string, interval int) string {
+	interval = max(interval, 1)
+	switch period {
+	case models.BillingPeriodWeek:
+		return fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", interval)
+	case models.BillingPeriodQuarter:
+		return fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d", 3*interval)
+	case models.BillingPeriodYear:
+		return fmt.Sprintf("FREQ=YEARLY;INTERVAL=%d", interval)
+	default:
+		return fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d", interval)
+	}
+}
+
+// escapeICalText экранирует значение типа TEXT (RFC 5545, 3.3.11).
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine переносит длинную строку: продолжение начинается с пробела, символы UTF-8 не разрываются.
+func foldICalLine(s string) string {
+	if len(s) <= icalLineLimit {
+		return s
+	}
+	var b strings.Builder
+	limit := icalLineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = icalLineLimit - 1 // пробел в начале продолжения тоже считается
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+func TestRenewalsCalendar(t *testing.T) {
+	endDate := "06-2026"
+	sub := &models.Subscription{
+		ID: "480850a7-0c6c-445d-8be6-3ff0b130168b", ServiceName: "Yandex, Plus", StartDate: "07-2025", EndDate: &endDate,
+		BillingPeriod: models.BillingPeriodQuarter, BillingInterval: 1, Currency: models.BaseCurrency,
+		UpdatedAt: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	calendar, err := renewalsCalendar([]service.Renewal{{Subscription: sub, Price: 39990}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTAMP:20250701T120000Z\r\n",
+		"DTSTART;VALUE=DATE:20250701\r\n",
+		"RRULE:FREQ=MONTHLY;INTERVAL=3;UNTIL=20260630\r\n",
+		`SUMMARY:Yandex\, Plus — 399.90 RUB` + "\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(calendar, want) {
+			t.Errorf("calendar has no %q:\n%s", want, calendar)
+		}
+	}
+}
+
+func TestFoldICalLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("Подписка ", 20)
+	folded := foldICalLine(line)
+	for _, part := range strings.Split(folded, "\r\n") {
+		if len(part) > icalLineLimit {
+			t.Errorf("line of %d octets: %q", len(part), part)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolded = %q", unfolded)
+	}
+}
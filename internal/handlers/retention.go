@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+const defaultDeletedTTL = 30 * 24 * time.Hour
+
+// RetentionService очистка удалённых подписок.
+type RetentionService interface {
+	Purge(ctx context.Context, retention time.Duration) (*models.PurgeResult, error)
+}
+
+var _ RetentionService = (*service.SubscriptionService)(nil)
+
+// RetentionHandler окончательно удаляет подписки, пролежавшие удалёнными дольше retention.
+type RetentionHandler struct {
+	service   RetentionService
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+func NewRetentionHandler(service RetentionService, retention time.Duration, logger *zap.Logger) *RetentionHandler {
+	if retention <= 0 {
+		retention = defaultDeletedTTL
+	}
+	return &RetentionHandler{
+		service:   service,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// PurgeSubscriptions godoc
+// @Summary Purge deleted subscriptions
+// @Description Permanently delete subscriptions that were soft-deleted longer ago than the configured retention (RETENTION_DELETED_TTL, 720h by default), together with their price history. Their change history is kept and gets a purged event
+// @Tags subscriptions
+// @Produce json
+// @Success 200 {object} models.PurgeResult
+// @Failure 500 {object} models.Problem
+// @Router /admin/subscriptions/purge [post]
+func (h *RetentionHandler) PurgeSubscriptions(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Purge(r.Context(), h.retention)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
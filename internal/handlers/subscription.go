@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go.uber.org/zap"
 
@@ -12,12 +15,36 @@ import (
 	"em-internship/internal/service"
 )
 
+// SubscriptionService бизнес-логика подписок, которую используют обработчики.
+type SubscriptionService interface {
+	Create(ctx context.Context, input models.CreateSubscriptionInput) (*models.Subscription, error)
+	GetByID(ctx context.Context, id string) (*models.Subscription, error)
+	GetAll(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error)
+	Replace(ctx context.Context, id string, input models.ReplaceSubscriptionInput, ifMatch int) (*models.Subscription, error)
+	Patch(ctx context.Context, id string, patch []byte, ifMatch int) (*models.Subscription, error)
+	Delete(ctx context.Context, id string, ifMatch int) error
+	Restore(ctx context.Context, id string) (*models.Subscription, error)
+	Batch(ctx context.Context, req models.BatchRequest) (*service.BatchOutcome, error)
+	Import(ctx context.Context, rows []models.SubscriptionImportRow, opts service.ImportOptions) (*service.ImportOutcome, error)
+	Export(ctx context.Context, params models.ListSubscriptionsParams, fn func(*models.Subscription) error) error
+	Renewals(ctx context.Context, userID string) ([]service.Renewal, error)
+	Summary(ctx context.Context, userID, currency string) (*models.UserSummary, error)
+	GetTotalCostForPeriod(ctx context.Context, params models.CostParams) (*models.TotalCostResponse, error)
+	GetCostBreakdown(ctx context.Context, params models.CostParams) (*models.CostBreakdownResponse, error)
+	ListPrices(ctx context.Context, id string) (*models.SubscriptionPriceList, error)
+	AddPrice(ctx context.Context, id string, input models.SubscriptionPriceInput) (*models.SubscriptionPrice, error)
+	DeletePrice(ctx context.Context, id, effectiveFrom string) error
+	History(ctx context.Context, id string) (*models.SubscriptionHistory, error)
+}
+
+var _ SubscriptionService = (*service.SubscriptionService)(nil)
+
 type SubscriptionHandler struct {
-	service *service.SubscriptionService
+	service SubscriptionService
 	logger  *zap.Logger
 }
 
-func NewSubscriptionHandler(service *service.SubscriptionService, logger *zap.Logger) *SubscriptionHandler {
+func NewSubscriptionHandler(service SubscriptionService, logger *zap.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		service: service,
 		logger:  logger,
@@ -26,31 +53,35 @@ func NewSubscriptionHandler(service *service.SubscriptionService, logger *zap.Lo
 
 // CreateSubscription godoc
 // @Summary Create a subscription
-// @Description Create a new subscription record
+// @Description Create a new subscription record. The service is taken from service_id, or looked up in the catalog by service_name and its aliases; a catalog service sets the canonical name and, without price, its default price
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body models.CreateSubscriptionInput true "Subscription data"
+// @Param Idempotency-Key header string false "Unique key of the request; a retry with the same key and body replays the stored response instead of creating a duplicate"
 // @Success 201 {object} models.Subscription
-// @Failure 400 {object} map[string]string
+// @Header 201 {string} ETag "Subscription version"
+// @Failure 400 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
 	var input models.CreateSubscriptionInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	sub, err := h.service.Create(r.Context(), input)
 	if err != nil {
-		h.logger.Error("failed to create subscription", zap.Error(err))
-		http.Error(w, `{"error":"failed to create subscription"}`, http.StatusInternalServerError)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(sub)
+	setETag(w, sub)
+	writeJSON(w, http.StatusCreated, sub)
 }
 
 // GetSubscription godoc
@@ -60,128 +91,365 @@ func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.
 // @Produce json
 // @Param id path string true "Subscription ID"
 // @Success 200 {object} models.Subscription
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
 	sub, err := h.service.GetByID(r.Context(), id)
 	if err != nil {
-		h.logger.Warn("subscription not found", zap.String("id", id), zap.Error(err))
-		http.Error(w, `{"error":"subscription not found"}`, http.StatusNotFound)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(sub)
+	setETag(w, sub)
+	writeJSON(w, http.StatusOK, sub)
 }
 
 // ListSubscriptions godoc
 // @Summary List all subscriptions
-// @Description Get paginated list of subscriptions
+// @Description Get paginated list of subscriptions with filters and sorting; total respects the filters
 // @Tags subscriptions
 // @Produce json
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
+// @Param user_id query string false "User ID filter"
+// @Param service_id query string false "Catalog service ID filter"
+// @Param service_name query string false "Service name filter: a name or alias from the catalog selects all subscriptions of that service, other names match exactly"
+// @Param service_name_prefix query string false "Service name prefix filter (case-insensitive)"
+// @Param min_price query string false "Minimum price, e.g. 199.90"
+// @Param max_price query string false "Maximum price, e.g. 999.99"
+// @Param active_in query string false "Active in month (MM-YYYY)"
+// @Param category query string false "Category filter (case-insensitive)"
+// @Param tag query []string false "Tag filter, repeat to require several tags" collectionFormat(multi)
+// @Param deleted query bool false "List deleted subscriptions that can still be restored instead of active ones"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(price, -price, start_date, -start_date, service_name, -service_name, created_at, -created_at) default(-created_at)
+// @Param cursor query string false "Keyset pagination cursor: pass empty value for the first page, then next_cursor from the previous response; offset and total are not used in this mode, sort must be created_at or -created_at"
 // @Success 200 {object} models.SubscriptionList
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	params, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	params.Limit, _ = strconv.Atoi(query.Get("limit"))
+	params.Offset, _ = strconv.Atoi(query.Get("offset"))
+	if query.Has("cursor") {
+		cursor := query.Get("cursor")
+		params.Cursor = &cursor
+	}
 
-	list, err := h.service.GetAll(r.Context(), limit, offset)
+	list, err := h.service.GetAll(r.Context(), params)
 	if err != nil {
-		h.logger.Error("failed to get subscriptions", zap.Error(err))
-		http.Error(w, `{"error":"failed to get subscriptions"}`, http.StatusInternalServerError)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// listParams читает фильтры и сортировку списка, общие для списка и выгрузки; при ошибке ответ уже отправлен.
+func listParams(w http.ResponseWriter, r *http.Request) (models.ListSubscriptionsParams, bool) {
+	query := r.URL.Query()
+	minPrice, err := parseOptionalMoney(query.Get("min_price"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "min_price must be a decimal with at most two fractional digits")
+		return models.ListSubscriptionsParams{}, false
+	}
+	maxPrice, err := parseOptionalMoney(query.Get("max_price"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "max_price must be a decimal with at most two fractional digits")
+		return models.ListSubscriptionsParams{}, false
+	}
+
+	return models.ListSubscriptionsParams{
+		SubscriptionFilter: models.SubscriptionFilter{
+			UserID:            query.Get("user_id"),
+			ServiceID:         query.Get("service_id"),
+			ServiceName:       query.Get("service_name"),
+			ServiceNamePrefix: query.Get("service_name_prefix"),
+			MinPrice:          minPrice,
+			MaxPrice:          maxPrice,
+			ActiveIn:          query.Get("active_in"),
+			Category:          query.Get("category"),
+			Tags:              query["tag"],
+			Deleted:           query.Get("deleted") == "true",
+		},
+		Sort: query.Get("sort"),
+	}, true
+}
+
+// parseOptionalMoney разбирает необязательный query-параметр с суммой, например "199.90".
+func parseOptionalMoney(s string) (*models.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := models.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
 }
 
 // UpdateSubscription godoc
-// @Summary Update subscription
-// @Description Update an existing subscription
+// @Summary Replace subscription
+// @Description Fully replace an existing subscription: all fields are required and have no defaults (price, billing_period, billing_interval and currency must be sent; service_name may be replaced by service_id), omitted end_date makes the subscription open-ended, omitted category and tags clear them. Price and currency of a subscription that started before the current month are changed only via /subscriptions/{id}/prices (409 otherwise); start_date and end_date must keep all price changes inside the subscription period
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path string true "Subscription ID"
-// @Param subscription body models.UpdateSubscriptionInput true "Subscription data"
+// @Param subscription body models.ReplaceSubscriptionInput true "Subscription data"
+// @Param If-Match header string false "ETag from a previous response; the update is rejected if the subscription has changed since"
 // @Success 200 {object} models.Subscription
-// @Failure 404 {object} map[string]string
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	var input models.UpdateSubscriptionInput
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	var input models.ReplaceSubscriptionInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.logger.Error("failed to decode request", zap.Error(err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sub, err := h.service.Replace(r.Context(), id, input, ifMatch)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	setETag(w, sub)
+	writeJSON(w, http.StatusOK, sub)
+}
+
+// PatchSubscription godoc
+// @Summary Patch subscription
+// @Description Partially update a subscription with JSON Merge Patch (RFC 7396): omitted fields are kept, null clears the field (e.g. "end_date": null makes the subscription open-ended). The same price and date rules as for PUT apply
+// @Tags subscriptions
+// @Accept application/merge-patch+json
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param patch body models.PatchSubscriptionInput true "Merge patch"
+// @Param If-Match header string false "ETag from a previous response; the update is rejected if the subscription has changed since"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if !isMergePatchContentType(r.Header.Get("Content-Type")) {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "use application/merge-patch+json")
+		return
+	}
+
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBodySize))
+	if err != nil {
+		h.logger.Warn("failed to read request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	sub, err := h.service.Update(r.Context(), id, input)
+	sub, err := h.service.Patch(r.Context(), id, patch, ifMatch)
 	if err != nil {
-		h.logger.Warn("failed to update subscription", zap.String("id", id), zap.Error(err))
-		http.Error(w, `{"error":"failed to update subscription"}`, http.StatusNotFound)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(sub)
+	setETag(w, sub)
+	writeJSON(w, http.StatusOK, sub)
+}
+
+const maxPatchBodySize = 1 << 20
+
+// isMergePatchContentType допускает application/merge-patch+json и, для совместимости, application/json.
+func isMergePatchContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
 }
 
 // DeleteSubscription godoc
 // @Summary Delete subscription
-// @Description Delete a subscription by ID
+// @Description Soft-delete a subscription by ID: it disappears from lists and cost totals but can be restored until it is purged after the retention period
 // @Tags subscriptions
 // @Produce json
 // @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag from a previous response; the delete is rejected if the subscription has changed since"
 // @Success 204
-// @Failure 404 {object} map[string]string
+// @Failure 404 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	if err := h.service.Delete(r.Context(), id); err != nil {
-		h.logger.Warn("failed to delete subscription", zap.String("id", id), zap.Error(err))
-		http.Error(w, `{"error":"subscription not found"}`, http.StatusNotFound)
+	ifMatch, err := parseIfMatch(r)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id, ifMatch); err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreSubscription godoc
+// @Summary Restore a deleted subscription
+// @Description Undo a soft delete; the subscription is back in lists and cost totals with a new version
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	sub, err := h.service.Restore(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	setETag(w, sub)
+	writeJSON(w, http.StatusOK, sub)
+}
+
 // GetTotalCost godoc
 // @Summary Get total cost for period
-// @Description Calculate total cost of subscriptions for a period with filters
+// @Description Calculate total cost of subscriptions for a period with filters: each price is charged in the months where its payments occur according to billing_period and billing_interval, or spread evenly over the active months with amortize=true
 // @Tags subscriptions
 // @Produce json
 // @Param user_id query string false "User ID filter"
-// @Param service_name query string false "Service name filter"
+// @Param service_id query string false "Catalog service ID filter"
+// @Param service_name query string false "Service name filter: a name or alias from the catalog selects all subscriptions of that service, other names match exactly"
 // @Param start_date query string true "Start date (MM-YYYY)"
 // @Param end_date query string true "End date (MM-YYYY)"
+// @Param amortize query bool false "Spread each payment evenly over the months it covers"
+// @Param currency query string false "ISO 4217 currency of the result, RUB by default; prices are converted with the exchange rate effective in each billed month"
 // @Success 200 {object} models.TotalCostResponse
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /subscriptions/total-cost [get]
 func (h *SubscriptionHandler) GetTotalCost(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	serviceName := r.URL.Query().Get("service_name")
-	startDate := r.URL.Query().Get("start_date")
-	endDate := r.URL.Query().Get("end_date")
+	params, ok := h.costParams(w, r)
+	if !ok {
+		return
+	}
 
-	if startDate == "" || endDate == "" {
-		http.Error(w, `{"error":"start_date and end_date are required"}`, http.StatusBadRequest)
+	response, err := h.service.GetTotalCostForPeriod(r.Context(), params)
+	if err != nil {
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	response, err := h.service.GetTotalCostForPeriod(r.Context(), userID, serviceName, startDate, endDate)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetCostBreakdown godoc
+// @Summary Get cost breakdown for period
+// @Description Split the cost of subscriptions for a period into buckets by month, service, user or category. Uses the same rules as total-cost; with group_by=month every month of the period is returned, including months without spending
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string false "User ID filter"
+// @Param service_id query string false "Catalog service ID filter"
+// @Param service_name query string false "Service name filter: a name or alias from the catalog selects all subscriptions of that service, other names match exactly"
+// @Param start_date query string true "Start date (MM-YYYY)"
+// @Param end_date query string true "End date (MM-YYYY)"
+// @Param group_by query string false "Grouping: month (default), service, user or category; subscriptions without a category are grouped under an empty key" Enums(month, service, user, category)
+// @Param amortize query bool false "Spread each payment evenly over the months it covers"
+// @Param currency query string false "ISO 4217 currency of the result, RUB by default; prices are converted with the exchange rate effective in each billed month"
+// @Success 200 {object} models.CostBreakdownResponse
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/cost-breakdown [get]
+func (h *SubscriptionHandler) GetCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.costParams(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetCostBreakdown(r.Context(), params)
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidDateFormat) {
-			http.Error(w, `{"error":"invalid date format: use MM-YYYY"}`, http.StatusBadRequest)
-			return
-		}
-		h.logger.Error("failed to calculate total cost", zap.Error(err))
-		http.Error(w, `{"error":"failed to calculate total cost"}`, http.StatusInternalServerError)
+		writeError(w, r, h.logger, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// costParams разбирает общие query-параметры расчёта стоимости; при ошибке ответ уже отправлен.
+func (h *SubscriptionHandler) costParams(w http.ResponseWriter, r *http.Request) (models.CostParams, bool) {
+	query := r.URL.Query()
+	params := models.CostParams{
+		UserID:      query.Get("user_id"),
+		ServiceID:   query.Get("service_id"),
+		ServiceName: query.Get("service_name"),
+		StartDate:   query.Get("start_date"),
+		EndDate:     query.Get("end_date"),
+		GroupBy:     query.Get("group_by"),
+		Currency:    strings.ToUpper(query.Get("currency")),
+	}
+
+	if params.StartDate == "" || params.EndDate == "" {
+		writeProblem(w, r, http.StatusBadRequest, "start_date and end_date are required")
+		return params, false
+	}
+
+	if v := query.Get("amortize"); v != "" {
+		amortize, err := strconv.ParseBool(v)
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, "amortize must be a boolean")
+			return params, false
+		}
+		params.Amortize = amortize
+	}
+
+	return params, true
 }
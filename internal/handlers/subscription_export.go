@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+)
+
+const (
+	// exportFlushRows через столько строк выгрузка отправляется клиенту, не дожидаясь конца
+	exportFlushRows = 100
+	// exportWriteTimeout сколько ждать записи следующей порции: общий WriteTimeout сервера оборвал бы
+	// длинную выгрузку, поэтому срок сдвигается после каждой отправленной порции
+	exportWriteTimeout = 30 * time.Second
+)
+
+// exportColumns колонки CSV-выгрузки; названия совпадают с полями импорта, поэтому файл можно загрузить обратно
+var exportColumns = []string{
+	"id", "service_id", "service_name", "price", "user_id", "start_date", "end_date",
+	"billing_period", "billing_interval", "currency", "category", "tags",
+	"version", "created_at", "updated_at", "deleted_at",
+}
+
+// ExportSubscriptions godoc
+// @Summary Export subscriptions
+// @Description Stream all subscriptions matching the filters of GET /subscriptions, without limit, as CSV (columns as in the import, tags separated by |) or JSON Lines (one subscription object per line). If the export fails after the first rows were sent, the response is cut off
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Output format" Enums(csv, jsonl) default(csv)
+// @Param user_id query string false "User ID filter"
+// @Param service_id query string false "Catalog service ID filter"
+// @Param service_name query string false "Service name filter: a name or alias from the catalog selects all subscriptions of that service, other names match exactly"
+// @Param service_name_prefix query string false "Service name prefix filter (case-insensitive)"
+// @Param min_price query string false "Minimum price, e.g. 199.90"
+// @Param max_price query string false "Maximum price, e.g. 999.99"
+// @Param active_in query string false "Active in month (MM-YYYY)"
+// @Param category query string false "Category filter (case-insensitive)"
+// @Param tag query []string false "Tag filter, repeat to require several tags" collectionFormat(multi)
+// @Param deleted query bool false "Export deleted subscriptions that can still be restored instead of active ones"
+// @Param sort query string false "Sort field, prefix with - for descending" Enums(price, -price, start_date, -start_date, service_name, -service_name, created_at, -created_at) default(-created_at)
+// @Success 200 {string} string "CSV or JSON Lines"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "jsonl" {
+		writeProblem(w, r, http.StatusBadRequest, "format must be one of: csv, jsonl")
+		return
+	}
+	params, ok := listParams(w, r)
+	if !ok {
+		return
+	}
+
+	out, err := newExportWriter(w, format)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+	err = h.service.Export(r.Context(), params, out.write)
+	if err == nil {
+		err = out.finish()
+	}
+	if err != nil {
+		if !out.started {
+			writeError(w, r, h.logger, err)
+			return
+		}
+		// заголовки уже отправлены: остаётся оборвать выгрузку, клиент увидит неполный файл
+		h.logger.Error("subscriptions export interrupted", zap.Error(err), zap.Int("rows", out.rows))
+	}
+}
+
+// exportWriter пишет подписки в ответ по мере чтения из хранилища; заголовки ответа отправляются
+// с первой строкой, чтобы ошибку до неё можно было вернуть обычным ответом.
+type exportWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  string
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	started bool
+	rows    int
+}
+
+func newExportWriter(w http.ResponseWriter, format string) (*exportWriter, error) {
+	buf := bufio.NewWriter(w)
+	e := &exportWriter{w: w, rc: http.NewResponseController(w), format: format, buf: buf, csv: csv.NewWriter(buf)}
+	// срок сервера отсчитывается от начала запроса, первая порция получает свой
+	if err := e.extendDeadline(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *exportWriter) write(sub *models.Subscription) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	if e.format == "csv" {
+		if err := e.csv.Write(subscriptionRecord(sub)); err != nil {
+			return err
+		}
+	} else if err := json.NewEncoder(e.buf).Encode(sub); err != nil {
+		return err
+	}
+
+	e.rows++
+	if e.rows%exportFlushRows == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// finish дописывает выгрузку; пустая выгрузка — CSV только с заголовком или пустой JSON Lines.
+func (e *exportWriter) finish() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+func (e *exportWriter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	contentType, ext := "text/csv; charset=utf-8", "csv"
+	if e.format == "jsonl" {
+		contentType, ext = "application/x-ndjson", "jsonl"
+	}
+	e.w.Header().Set("Content-Type", contentType)
+	e.w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+ext+`"`)
+	e.w.WriteHeader(http.StatusOK)
+
+	if e.format == "csv" {
+		return e.csv.Write(exportColumns)
+	}
+	return nil
+}
+
+func (e *exportWriter) flush() error {
+	if e.format == "csv" {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := e.buf.Flush(); err != nil {
+		return err
+	}
+	if err := e.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return e.extendDeadline()
+}
+
+// extendDeadline даёт exportWriteTimeout на запись следующей порции.
+func (e *exportWriter) extendDeadline() error {
+	if err := e.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// subscriptionRecord строка CSV в порядке exportColumns.
+func subscriptionRecord(sub *models.Subscription) []string {
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	deletedAt := ""
+	if sub.DeletedAt != nil {
+		deletedAt = sub.DeletedAt.Format(time.RFC3339)
+	}
+	return []string{
+		sub.ID,
+		optional(sub.ServiceID),
+		sub.ServiceName,
+		sub.Price.String(),
+		sub.UserID,
+		sub.StartDate,
+		optional(sub.EndDate),
+		sub.BillingPeriod,
+		strconv.Itoa(sub.BillingInterval),
+		sub.Currency,
+		sub.Category,
+		strings.Join(sub.Tags, models.CSVTagSeparator),
+		strconv.Itoa(sub.Version),
+		sub.CreatedAt.Format(time.RFC3339),
+		sub.UpdatedAt.Format(time.RFC3339),
+		deletedAt,
+	}
+}
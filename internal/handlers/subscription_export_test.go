@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"em-internship/internal/models"
+)
+
+func TestExportWriter(t *testing.T) {
+	endDate := "12-2025"
+	created := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	sub := &models.Subscription{
+		ID: "480850a7-0c6c-445d-8be6-3ff0b130168b", ServiceName: "Yandex, Plus", Price: 39990,
+		UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "07-2025", EndDate: &endDate,
+		BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency,
+		Tags: []string{"family", "music"}, Version: 2, CreatedAt: created, UpdatedAt: created,
+	}
+
+	rec := httptest.NewRecorder()
+	out, err := newExportWriter(rec, "csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := out.write(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := out.finish(); err != nil {
+		t.Fatal(err)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		t.Fatalf("records = %q", records)
+	}
+	row := records[1]
+	if row[2] != "Yandex, Plus" || row[3] != "399.90" || row[6] != "12-2025" || row[11] != "family|music" || row[15] != "" {
+		t.Errorf("row = %q", row)
+	}
+
+	// пустая выгрузка в JSON Lines — пустое тело
+	rec = httptest.NewRecorder()
+	out, err = newExportWriter(rec, "jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.finish(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("empty jsonl export: %q, %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestExportWriter_OutlivesServerWriteTimeout(t *testing.T) {
+	sub := &models.Subscription{ID: "480850a7-0c6c-445d-8be6-3ff0b130168b", ServiceName: "Netflix", Price: 99900}
+	const batches = 4
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, err := newExportWriter(w, "jsonl")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for i := range batches * exportFlushRows {
+			if i > 0 && i%exportFlushRows == 0 {
+				time.Sleep(50 * time.Millisecond) // медленное чтение из хранилища
+			}
+			if err := out.write(sub); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		if err := out.finish(); err != nil {
+			t.Error(err)
+		}
+	}))
+	// выгрузка идёт дольше общего срока записи сервера
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("export was cut off: %v", err)
+	}
+	if lines := strings.Count(string(body), "\n"); lines != batches*exportFlushRows {
+		t.Errorf("rows = %d, want %d", lines, batches*exportFlushRows)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/service"
+)
+
+// ImportSubscriptions godoc
+// @Summary Import subscriptions from CSV
+// @Description Create subscriptions from a CSV file with a header row. Columns are named like the fields of POST /subscriptions (service_id, service_name, price, user_id, start_date, end_date, billing_period, billing_interval, currency, category, tags) or mapped with the column parameter; other columns are ignored. Dates are MM-YYYY, YYYY-MM or YYYY-MM-DD, tags are separated by |. With a delimiter other than comma the price may use a decimal comma, e.g. 999,00. Every row is validated like POST /subscriptions. With dry_run=true nothing is saved and the response lists the errors of all rows. Otherwise the subscriptions are saved in one transaction only if every row is valid; if any row fails the response is 422 and nothing is saved
+// @Tags subscriptions
+// @Accept text/csv
+// @Produce json
+// @Param file body string true "CSV file"
+// @Param dry_run query bool false "Only validate the rows"
+// @Param column query []string false "Column mapping field:header, e.g. price:Monthly cost; repeat for several fields" collectionFormat(multi)
+// @Param delimiter query string false "Column delimiter, one character" default(,)
+// @Param Idempotency-Key header string false "Unique key of the request; a retry with the same key and body replays the stored response instead of importing the file again"
+// @Success 200 {object} models.SubscriptionImportResult
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.SubscriptionImportResult
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/import [post]
+func (h *SubscriptionHandler) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	columns, err := importColumns(query["column"])
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	delimiter := ','
+	if value := query.Get("delimiter"); value != "" {
+		if utf8.RuneCountInString(value) != 1 {
+			writeProblem(w, r, http.StatusBadRequest, "delimiter must be a single character")
+			return
+		}
+		delimiter, _ = utf8.DecodeRuneInString(value)
+	}
+
+	rows, err := readSubscriptionsCSV(http.MaxBytesReader(w, r.Body, maxImportBodySize), columns, delimiter)
+	if err != nil {
+		h.logger.Warn("failed to read subscriptions file", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	outcome, err := h.service.Import(r.Context(), rows, service.ImportOptions{
+		DryRun:       query.Get("dry_run") == "true",
+		DecimalComma: delimiter != ',',
+	})
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	result := models.SubscriptionImportResult{
+		DryRun:   outcome.DryRun,
+		Rows:     outcome.Rows,
+		Valid:    outcome.Valid,
+		Imported: outcome.Imported,
+		Errors:   make([]models.ImportRowError, 0, len(outcome.Errors)),
+	}
+	for _, rowErr := range outcome.Errors {
+		result.Errors = append(result.Errors, models.ImportRowError{Row: rowErr.Row, Error: errorProblem(r, h.logger, rowErr.Err)})
+	}
+
+	status := http.StatusOK
+	if !outcome.DryRun && len(outcome.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, result)
+}
+
+// importColumns сопоставляет заголовки файла полям подписки: по умолчанию заголовок совпадает с полем,
+// mapping вида field:header переименовывает колонку. Ключи — заголовки в нижнем регистре.
+func importColumns(mapping []string) (map[string]string, error) {
+	headers := make(map[string]string, len(models.SubscriptionImportColumns))
+	for _, field := range models.SubscriptionImportColumns {
+		headers[field] = field
+	}
+	for _, pair := range mapping {
+		field, header, ok := strings.Cut(pair, ":")
+		field = strings.TrimSpace(field)
+		header = strings.TrimSpace(header)
+		if !ok || header == "" {
+			return nil, fmt.Errorf("column %q must be in the form field:header", pair)
+		}
+		if !slices.Contains(models.SubscriptionImportColumns, field) {
+			return nil, fmt.Errorf("unknown field %q, use one of: %s", field, strings.Join(models.SubscriptionImportColumns, ", "))
+		}
+		headers[field] = header
+	}
+
+	columns := make(map[string]string, len(headers))
+	for field, header := range headers {
+		columns[strings.ToLower(header)] = field
+	}
+	return columns, nil
+}
+
+// readSubscriptionsCSV читает строки файла; columns — заголовок в нижнем регистре → поле подписки.
+func readSubscriptionsCSV(body io.Reader, columns map[string]string, delimiter rune) ([]models.SubscriptionImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.Comma = delimiter
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, len(header))
+	found := make(map[string]bool, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // BOM из Excel
+		}
+		fields[i] = columns[strings.ToLower(strings.TrimSpace(name))]
+		found[fields[i]] = true
+	}
+	for _, field := range []string{"user_id", "start_date"} {
+		if !found[field] {
+			return nil, fmt.Errorf("missing column for %s", field)
+		}
+	}
+	if !found["service_name"] && !found["service_id"] {
+		return nil, errors.New("missing column for service_name or service_id")
+	}
+
+	var rows []models.SubscriptionImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := models.SubscriptionImportRow{Line: line, Values: make(map[string]string, len(record))}
+		for i, value := range record {
+			if i < len(fields) && fields[i] != "" {
+				row.Values[fields[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no rows")
+	}
+	return rows, nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadSubscriptionsCSV(t *testing.T) {
+	columns, err := importColumns([]string{"service_name:Сервис", "price:Стоимость"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := "\ufeffСервис;Стоимость;user_id;start_date;Комментарий\n" +
+		"Netflix;999,00;60601fee-2bf1-4721-ae6f-7636e79a0cba;2025-07;семейная\n" +
+		"\"Yandex; Plus\";399.90;60601fee-2bf1-4721-ae6f-7636e79a0cba;07-2025\n"
+
+	rows, err := readSubscriptionsCSV(strings.NewReader(file), columns, ';')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	if rows[0].Line != 2 || rows[0].Values["service_name"] != "Netflix" || rows[0].Values["price"] != "999,00" {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if _, ok := rows[0].Values["Комментарий"]; ok {
+		t.Errorf("unmapped column was read: %+v", rows[0].Values)
+	}
+	if rows[1].Line != 3 || rows[1].Values["service_name"] != "Yandex; Plus" {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+
+	if _, err := readSubscriptionsCSV(strings.NewReader("service_name,price,start_date\n"), columns, ','); err == nil {
+		t.Error("expected error for missing user_id column")
+	}
+	if _, err := importColumns([]string{"cost:Стоимость"}); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
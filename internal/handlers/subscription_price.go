@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+)
+
+// ListPrices godoc
+// @Summary Get subscription price history
+// @Description List the price effective from start_date followed by every price change in chronological order
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.SubscriptionPriceList
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) ListPrices(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	list, err := h.service.ListPrices(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// AddPrice godoc
+// @Summary Add a price change
+// @Description Change the subscription price starting from a month; earlier months keep the previous price in cost calculations
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param price body models.SubscriptionPriceInput true "Price change"
+// @Success 201 {object} models.SubscriptionPrice
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/prices [post]
+func (h *SubscriptionHandler) AddPrice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var input models.SubscriptionPriceInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.logger.Warn("failed to decode request", zap.Error(err))
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	price, err := h.service.AddPrice(r.Context(), id, input)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, price)
+}
+
+// DeletePrice godoc
+// @Summary Delete a price change
+// @Tags subscriptions
+// @Param id path string true "Subscription ID"
+// @Param effective_from path string true "Month of the price change (MM-YYYY)"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/prices/{effective_from} [delete]
+func (h *SubscriptionHandler) DeletePrice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	effectiveFrom := r.PathValue("effective_from")
+
+	if err := h.service.DeletePrice(r.Context(), id, effectiveFrom); err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetHistory godoc
+// @Summary Get subscription change history
+// @Description List changes of the subscription and its prices from oldest to newest with the changed fields, the X-Actor of the request and its request ID. The history remains available after the subscription is deleted
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.SubscriptionHistory
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /subscriptions/{id}/history [get]
+func (h *SubscriptionHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	history, err := h.service.History(r.Context(), id)
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
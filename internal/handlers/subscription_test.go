@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/service"
+)
+
+func TestUpdateSubscription_RequiresAllFields(t *testing.T) {
+	svc := service.NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), service.SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+	h := NewSubscriptionHandler(svc, zap.NewNop())
+
+	sub, err := svc.Create(context.Background(), models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 120000, UserID: "60601fee-2bf1-4721-ae6f-7636e79a0cba", StartDate: "07-2025",
+		BillingPeriod: models.BillingPeriodYear, BillingInterval: 1, Currency: "USD",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// без billing_period, billing_interval и currency замена отклоняется, а не сбрасывает их в month и RUB
+	body := `{"service_name": "Yandex Plus", "price": "1200.00", "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025"}`
+	r := httptest.NewRequest(http.MethodPut, "/subscriptions/"+sub.ID, strings.NewReader(body))
+	r.SetPathValue("id", sub.ID)
+	w := httptest.NewRecorder()
+	h.UpdateSubscription(w, r)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("partial PUT: status %d, want 422: %s", w.Code, w.Body)
+	}
+
+	var problem models.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	missing := map[string]bool{}
+	for _, violation := range problem.Errors {
+		if violation.Rule == "required" {
+			missing[violation.Field] = true
+		}
+	}
+	for _, field := range []string{"billing_period", "billing_interval", "currency"} {
+		if !missing[field] {
+			t.Errorf("violations = %+v, want %s required", problem.Errors, field)
+		}
+	}
+
+	if current, _ := svc.GetByID(context.Background(), sub.ID); current.BillingPeriod != models.BillingPeriodYear || current.Currency != "USD" {
+		t.Errorf("subscription after rejected PUT = %+v", current)
+	}
+}
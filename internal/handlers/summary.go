@@ -0,0 +1,27 @@
+package handlers
+
+import "net/http"
+
+// GetUserSummary godoc
+// @Summary Spending summary of a user
+// @Description Summary of the user's subscriptions for the current month: number of active subscriptions, monthly spend (payments spread over the months they cover, as total-cost with amortize=true), spend from January to the current month (as total-cost), the service with the highest monthly spend and active subscriptions ending this or next month
+// @Tags subscriptions
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param currency query string false "ISO 4217 currency of the amounts, RUB by default"
+// @Success 200 {object} models.UserSummary
+// @Failure 400 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /users/{user_id}/summary [get]
+func (h *SubscriptionHandler) GetUserSummary(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("user_id")
+
+	summary, err := h.service.Summary(r.Context(), userID, r.URL.Query().Get("currency"))
+	if err != nil {
+		writeError(w, r, h.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,52 @@
+package models
+
+import "encoding/json"
+
+// Режимы выполнения пакета
+const (
+	BatchModeAtomic  = "atomic"  // ошибка любой операции откатывает весь пакет
+	BatchModePartial = "partial" // ошибка откатывает только свою операцию
+)
+
+// Операции пакета
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchRequest пакет операций над подписками, выполняется в одной транзакции
+type BatchRequest struct {
+	Mode string `json:"mode,omitempty" validate:"omitempty,oneof=atomic partial" enums:"atomic,partial" default:"atomic"`
+	// не больше 1000, чтобы транзакция не держала блокировки слишком долго
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=1000"`
+}
+
+// BatchOperation одна операция пакета
+type BatchOperation struct {
+	Op string `json:"op" validate:"required,oneof=create update delete" enums:"create,update,delete"`
+	// подписка для update и delete
+	ID string `json:"id,omitempty" validate:"required_unless=Op create"`
+	// ожидаемая версия для update и delete, как If-Match; 0 — без проверки
+	Version int `json:"version,omitempty" validate:"gte=0"`
+	// для create — тело POST /subscriptions, для update — тело PUT /subscriptions/{id}
+	Subscription json.RawMessage `json:"subscription,omitempty" validate:"required_unless=Op delete" swaggertype:"object"`
+}
+
+// BatchResult результаты операций в порядке запроса
+type BatchResult struct {
+	Mode      string            `json:"mode" enums:"atomic,partial"`
+	Succeeded int               `json:"succeeded"` // сохранённые операции
+	Failed    int               `json:"failed"`    // в режиме atomic при ошибке — все операции
+	Items     []BatchItemResult `json:"items"`
+}
+
+// BatchItemResult результат одной операции: статус такой же, как у отдельного запроса
+type BatchItemResult struct {
+	Index        int           `json:"index"` // номер операции в запросе, с 0
+	Op           string        `json:"op" enums:"create,update,delete"`
+	ID           string        `json:"id,omitempty"`
+	Status       int           `json:"status" example:"201"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Error        *Problem      `json:"error,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Budget месячный бюджет пользователя на подписки. Расходы считаются как total-cost
+// за текущий месяц с amortize=true в валюте бюджета.
+type Budget struct {
+	UserID     string `json:"user_id"`
+	Amount     Money  `json:"amount" swaggertype:"string" example:"1500.00"`
+	Currency   string `json:"currency"`
+	WebhookURL string `json:"webhook_url,omitempty"` // сюда отправляется POST с BudgetAlert
+	// расходы текущего месяца превышали бюджет при последней проверке; предупреждение создаётся
+	// только при переходе через бюджет, в новом месяце — снова
+	Exceeded      bool      `json:"exceeded"`
+	ExceededMonth string    `json:"exceeded_month,omitempty" example:"07-2025"` // MM-YYYY, месяц, в котором бюджет превышен
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// для создания и замены бюджета
+type BudgetInput struct {
+	Amount     Money  `json:"amount" validate:"required,gt=0" swaggertype:"string" example:"1500.00"`
+	Currency   string `json:"currency,omitempty" validate:"omitempty,currency"` // по умолчанию RUB
+	WebhookURL string `json:"webhook_url,omitempty" validate:"omitempty,http_url,max=2048"`
+}
+
+// BudgetAlert предупреждение: после изменения подписок расходы месяца превысили бюджет
+type BudgetAlert struct {
+	ID             int64     `json:"id"`
+	UserID         string    `json:"user_id"`
+	Month          string    `json:"month" example:"07-2025"` // MM-YYYY
+	Spend          Money     `json:"spend" swaggertype:"string" example:"1699.80"`
+	Amount         Money     `json:"amount" swaggertype:"string" example:"1500.00"` // бюджет на момент превышения
+	Currency       string    `json:"currency"`
+	SubscriptionID string    `json:"subscription_id,omitempty"` // пусто, если бюджет превышен при его изменении
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// предупреждения о превышении бюджета, от новых к старым
+type BudgetAlertList struct {
+	Items []BudgetAlert `json:"items"`
+}
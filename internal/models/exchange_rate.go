@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BaseCurrency валюта, к которой задаются курсы; её курс всегда 1.
+const BaseCurrency = "RUB"
+
+// ExchangeRate курс валюты к BaseCurrency: 1 единица Currency = Rate единиц BaseCurrency.
+// Действует с месяца EffectiveFrom до следующего курса этой валюты.
+type ExchangeRate struct {
+	Currency      string    `json:"currency"`
+	EffectiveFrom string    `json:"effective_from"`
+	Rate          string    `json:"rate" example:"92.5"` // десятичная строка, без потери точности
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// для создания или замены курса
+type ExchangeRateInput struct {
+	Currency      string `json:"currency" validate:"required,currency"`
+	EffectiveFrom string `json:"effective_from" validate:"required,month_year"`
+	Rate          string `json:"rate" validate:"required,positive_decimal" example:"92.5"`
+}
+
+// список курсов
+type ExchangeRateList struct {
+	Items []ExchangeRate `json:"items"`
+}
+
+// результат импорта курсов из файла
+type ExchangeRateImportResult struct {
+	Imported int `json:"imported"`
+}
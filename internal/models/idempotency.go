@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// сохранённый ответ на запрос с заголовком Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string            // sha256 метода, пути и тела запроса
+	StatusCode  int               // 0 — запрос ещё выполняется
+	Headers     map[string]string // заголовки ответа, которые нужно повторить
+	Body        []byte
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Денежные суммы хранятся в минимальных единицах валюты (копейках, центах): две цифры после запятой.
+const moneyScale = 100
+
+// maxMoneyDigits ограничение целой части, чтобы сумма с копейками помещалась в int64 с запасом
+const maxMoneyDigits = 15
+
+var ErrInvalidMoney = errors.New("invalid amount: use a decimal with at most two fractional digits")
+
+var moneyRegex = regexp.MustCompile(`^(-?)(\d+)(?:\.(\d{1,2}))?$`)
+
+// Money сумма в минимальных единицах валюты. В JSON отдаётся строкой "400.00",
+// принимается строкой или числом с не более чем двумя знаками после запятой.
+type Money int64
+
+// ParseMoney разбирает десятичную запись суммы: "400", "400.5", "-0.99".
+func ParseMoney(s string) (Money, error) {
+	m := moneyRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || len(strings.TrimLeft(m[2], "0")) > maxMoneyDigits {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	units, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	cents, _ := strconv.ParseInt((m[3] + "00")[:2], 10, 64)
+
+	v := Money(units*moneyScale + cents)
+	if m[1] == "-" {
+		v = -v
+	}
+	return v, nil
+}
+
+// String форматирует сумму с двумя знаками после запятой.
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
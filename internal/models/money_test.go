@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Money
+		err  bool
+	}{
+		{"400", 40000, false},
+		{"400.5", 40050, false},
+		{"400.05", 40005, false},
+		{"0.99", 99, false},
+		{"-12.30", -1230, false},
+		{"400.001", 0, true},
+		{"1e3", 0, true},
+		{"12,50", 0, true},
+		{"", 0, true},
+		{"1000000000000000", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.s)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, %v; want %d, error %v", tt.s, got, err, tt.want, tt.err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidMoney) {
+			t.Errorf("ParseMoney(%q): expected ErrInvalidMoney, got %v", tt.s, err)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		A Money `json:"a"`
+		B Money `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 19.99, "b": "400"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1999 || v.B != 40000 {
+		t.Errorf("decoded %d, %d", v.A, v.B)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"a":"19.99","b":"400.00"}` {
+		t.Errorf("encoded %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"a": 0.001}`), &v); err == nil {
+		t.Error("expected error for three fractional digits")
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// Problem описание ошибки по RFC 7807, отдаётся с Content-Type application/problem+json
+// @Description Ошибка в формате RFC 7807
+type Problem struct {
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Status    int              `json:"status"`
+	Detail    string           `json:"detail,omitempty"`
+	Instance  string           `json:"instance,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+	Errors    []FieldViolation `json:"errors,omitempty"`
+}
+
+// нарушение правила валидации конкретного поля
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
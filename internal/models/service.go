@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+)
+
+// Service сервис из каталога. Подписки ссылаются на него через service_id,
+// а при создании по service_name сервис находится по названию или синониму.
+type Service struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     string    `json:"category,omitempty"`
+	DefaultPrice *Money    `json:"default_price,omitempty" swaggertype:"string" example:"399.00"`
+	Currency     string    `json:"currency"` // валюта DefaultPrice
+	Website      string    `json:"website,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// для создания и полной замены сервиса
+type ServiceInput struct {
+	Name         string   `json:"name" validate:"required,min=1,max=255"`
+	Aliases      []string `json:"aliases,omitempty" validate:"omitempty,max=50,dive,min=1,max=255"`
+	Category     string   `json:"category,omitempty" validate:"omitempty,max=100"`
+	DefaultPrice *Money   `json:"default_price,omitempty" validate:"omitempty,gt=0" swaggertype:"string" example:"399.00"`
+	Currency     string   `json:"currency,omitempty" validate:"omitempty,currency"` // по умолчанию RUB
+	Website      string   `json:"website,omitempty" validate:"omitempty,url,max=2048"`
+}
+
+// фильтр списка сервисов
+type ServiceFilter struct {
+	Category string `query:"category"`
+	Query    string `query:"q"` // начало названия или синонима
+}
+
+// список сервисов
+type ServiceList struct {
+	Items []Service `json:"items"`
+}
+
+// NormalizeServiceName приводит название сервиса к виду для сравнения:
+// нижний регистр, без пробелов по краям, одиночные пробелы между словами.
+func NormalizeServiceName(name string) string {
+	return NormalizeLabel(name)
+}
+
+// ServiceNames нормализованные название и синонимы сервиса без повторов.
+func ServiceNames(name string, aliases []string) []string {
+	seen := make(map[string]bool, len(aliases)+1)
+	names := make([]string, 0, len(aliases)+1)
+	for _, n := range append([]string{name}, aliases...) {
+		n = NormalizeServiceName(n)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	return names
+}
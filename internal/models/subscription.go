@@ -1,48 +1,209 @@
 package models
 
 import (
+	"sort"
+	"strings"
 	"time"
 )
 
+// Периоды оплаты подписки
+const (
+	BillingPeriodWeek    = "week"
+	BillingPeriodMonth   = "month"
+	BillingPeriodQuarter = "quarter"
+	BillingPeriodYear    = "year"
+)
+
 // Subscription модель подписки
 // @Description Модель подписки на сервис
 type Subscription struct {
-	ID          string    `json:"id" db:"id"`
-	ServiceName string    `json:"service_name" db:"service_name" validate:"required,min=1,max=255"`
-	Price       int       `json:"price" db:"price" validate:"required"`
-	UserID      string    `json:"user_id" db:"user_id" validate:"required,uuid"`
-	StartDate   string    `json:"start_date" db:"start_date" validate:"required"`
-	EndDate     *string   `json:"end_date,omitempty" db:"end_date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          string  `json:"id" db:"id"`
+	ServiceName string  `json:"service_name" db:"service_name" validate:"required,min=1,max=255"`
+	ServiceID   *string `json:"service_id,omitempty" db:"service_id"`                                            // сервис из каталога, если название найдено
+	Price       Money   `json:"price" db:"price" validate:"required,gt=0" swaggertype:"string" example:"400.00"` // в копейках, в JSON — "400.00"
+	UserID      string  `json:"user_id" db:"user_id" validate:"required,uuid"`
+	StartDate   string  `json:"start_date" db:"start_date" validate:"required,month_year"`
+	EndDate     *string `json:"end_date,omitempty" db:"end_date" validate:"omitempty,month_year"`
+	// Price списывается раз в BillingInterval периодов BillingPeriod, например раз в 3 месяца
+	BillingPeriod   string    `json:"billing_period" db:"billing_period" validate:"required,oneof=week month quarter year"`
+	BillingInterval int       `json:"billing_interval" db:"billing_interval" validate:"required,gte=1,lte=52"`
+	Currency        string    `json:"currency" db:"currency" validate:"required,currency"` // валюта Price, ISO 4217
+	Category        string    `json:"category,omitempty" db:"category" validate:"max=100"`
+	Tags            []string  `json:"tags" validate:"max=20,dive,min=1,max=50"` // в нижнем регистре, по алфавиту
+	Version         int       `json:"version" db:"version"`                     // растёт при каждом изменении, отдаётся как ETag
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+	// время мягкого удаления; удалённую подписку можно восстановить до очистки
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
 // для создания подписки
 type CreateSubscriptionInput struct {
-	ServiceName string `json:"service_name" validate:"required,min=1,max=255"`
-	Price       int    `json:"price" validate:"required,gt=0"`
-	UserID      string `json:"user_id" validate:"required,uuid"`
-	StartDate   string `json:"start_date" validate:"required,month_year"`
-	EndDate     string `json:"end_date,omitempty" validate:"omitempty,month_year"`
+	// сервис из каталога; без него service_name ищется по названиям и синонимам каталога
+	ServiceID   string `json:"service_id,omitempty" validate:"omitempty,uuid"`
+	ServiceName string `json:"service_name" validate:"omitempty,max=255"`
+	// строка или число, не больше двух знаков после запятой; без цены берётся default_price сервиса
+	Price     Money  `json:"price" validate:"omitempty,gt=0" swaggertype:"string" example:"400.00"`
+	UserID    string `json:"user_id" validate:"required,uuid"`
+	StartDate string `json:"start_date" validate:"required,month_year"`
+	EndDate   string `json:"end_date,omitempty" validate:"omitempty,month_year"`
+	// по умолчанию month и 1 — ежемесячная оплата
+	BillingPeriod   string `json:"billing_period,omitempty" validate:"omitempty,oneof=week month quarter year"`
+	BillingInterval int    `json:"billing_interval,omitempty" validate:"omitempty,gte=1,lte=52"`
+	Currency        string `json:"currency,omitempty" validate:"omitempty,currency"` // по умолчанию RUB
+	// по умолчанию категория сервиса из каталога
+	Category string   `json:"category,omitempty" validate:"omitempty,max=100"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+// для полной замены подписки (PUT): передаются все поля, значений по умолчанию нет —
+// без них PUT незаметно сбрасывал бы период оплаты и валюту. Отсутствие end_date — бессрочная подписка,
+// отсутствие category и tags очищает их.
+type ReplaceSubscriptionInput struct {
+	ServiceID       string   `json:"service_id,omitempty" validate:"omitempty,uuid"`
+	ServiceName     string   `json:"service_name" validate:"required_without=ServiceID,max=255"` // с service_id берётся из каталога
+	Price           Money    `json:"price" validate:"required,gt=0" swaggertype:"string" example:"400.00"`
+	UserID          string   `json:"user_id" validate:"required,uuid"`
+	StartDate       string   `json:"start_date" validate:"required,month_year"`
+	EndDate         string   `json:"end_date,omitempty" validate:"omitempty,month_year"`
+	BillingPeriod   string   `json:"billing_period" validate:"required,oneof=week month quarter year"`
+	BillingInterval int      `json:"billing_interval" validate:"required,gte=1,lte=52"`
+	Currency        string   `json:"currency" validate:"required,currency"`
+	Category        string   `json:"category,omitempty" validate:"omitempty,max=100"`
+	Tags            []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+// тело PATCH в формате JSON Merge Patch (RFC 7396): отсутствующие поля не меняются, null очищает поле.
+// Описывает схему для документации, сам запрос разбирается как merge patch.
+type PatchSubscriptionInput struct {
+	ServiceID       *string `json:"service_id,omitempty" extensions:"x-nullable"`
+	ServiceName     *string `json:"service_name,omitempty"`
+	Price           *Money  `json:"price,omitempty" swaggertype:"string" example:"400.00"`
+	UserID          *string `json:"user_id,omitempty"`
+	StartDate       *string `json:"start_date,omitempty"`
+	EndDate         *string `json:"end_date,omitempty" extensions:"x-nullable"`
+	BillingPeriod   *string `json:"billing_period,omitempty"`
+	BillingInterval *int    `json:"billing_interval,omitempty"`
+	Currency        *string `json:"currency,omitempty"`
+	Category        *string `json:"category,omitempty" extensions:"x-nullable"`
+	// заменяет теги целиком
+	Tags *[]string `json:"tags,omitempty" extensions:"x-nullable"`
 }
 
-// для обновления подписки
-type UpdateSubscriptionInput struct {
-	ServiceName *string `json:"service_name,omitempty" validate:"omitempty,min=1,max=255"`
-	Price       *int    `json:"price,omitempty" validate:"omitempty,gt=0"`
-	UserID      *string `json:"user_id,omitempty" validate:"omitempty,uuid"`
-	StartDate   *string `json:"start_date,omitempty" validate:"omitempty,month_year"`
-	EndDate     *string `json:"end_date,omitempty" validate:"omitempty,month_year"`
+// стоимость одной подписки за период: цена × число списаний в периоде,
+// при амортизации — цена, равномерно распределённая по активным месяцам
+type SubscriptionCost struct {
+	SubscriptionID  string `json:"subscription_id"`
+	ServiceName     string `json:"service_name"`
+	Price           Money  `json:"price" swaggertype:"string" example:"400.00"`
+	Currency        string `json:"currency"` // валюта Price; Cost — в валюте ответа
+	BillingPeriod   string `json:"billing_period"`
+	BillingInterval int    `json:"billing_interval"`
+	Months          int    `json:"months"`             // активные месяцы внутри периода
+	Payments        int    `json:"payments,omitempty"` // списания внутри периода; без амортизации
+	Cost            Money  `json:"cost" swaggertype:"string" example:"1200.00"`
+}
+
+// параметры расчёта стоимости за период
+type CostParams struct {
+	UserID      string
+	ServiceID   string
+	ServiceName string
+	StartDate   string
+	EndDate     string
+	GroupBy     string // только для разбивки
+	Amortize    bool   // распределять списания равномерно по месяцам
+	Currency    string // валюта итоговых сумм
+	// курсы для пересчёта в Currency; заполняет сервис
+	Rates []ExchangeRate
 }
 
 // итоговая стоимость
 type TotalCostResponse struct {
-	TotalCost int `json:"total_cost"`
-	Count     int `json:"count"`
+	TotalCost Money              `json:"total_cost" swaggertype:"string" example:"1200.00"`
+	Currency  string             `json:"currency"`
+	Count     int                `json:"count"`
+	Amortized bool               `json:"amortized"`
+	Items     []SubscriptionCost `json:"items"`
+}
+
+// фильтры списка подписок; пустые поля не ограничивают выборку
+type SubscriptionFilter struct {
+	UserID            string `query:"user_id" validate:"omitempty,uuid"`
+	ServiceID         string `query:"service_id" validate:"omitempty,uuid"`
+	ServiceName       string `query:"service_name"`        // точное совпадение
+	ServiceNamePrefix string `query:"service_name_prefix"` // префикс без учёта регистра
+	MinPrice          *Money `query:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice          *Money `query:"max_price" validate:"omitempty,gte=0"`
+	ActiveIn          string `query:"active_in" validate:"omitempty,month_year"` // подписка активна в месяце MM-YYYY
+	Category          string `query:"category" validate:"omitempty,max=100"`
+	// подписка отмечена всеми тегами
+	Tags []string `query:"tag" validate:"omitempty,max=20,dive,min=1,max=50"`
+	// true — только удалённые подписки, которые ещё можно восстановить
+	Deleted bool `query:"deleted"`
+}
+
+// параметры запроса списка подписок
+type ListSubscriptionsParams struct {
+	SubscriptionFilter
+	// поле сортировки, "-" в начале — по убыванию; по умолчанию -created_at
+	Sort   string `query:"sort" validate:"omitempty,oneof=price -price start_date -start_date service_name -service_name created_at -created_at"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+	// курсор keyset-пагинации; nil — режим limit/offset, пустая строка — первая страница
+	Cursor *string `query:"cursor"`
+}
+
+// сумма и число подписок в одной группе разбивки стоимости
+type CostBucket struct {
+	Key    string `json:"key"` // MM-YYYY, название сервиса, user_id или категория (пусто — без категории) — в зависимости от group_by
+	Amount Money  `json:"amount" swaggertype:"string" example:"1200.00"`
+	Count  int    `json:"count"`
+}
+
+// разбивка стоимости за период по месяцам, сервисам, пользователям или категориям
+type CostBreakdownResponse struct {
+	GroupBy   string       `json:"group_by"`
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	TotalCost Money        `json:"total_cost" swaggertype:"string" example:"1200.00"`
+	Currency  string       `json:"currency"`
+	Amortized bool         `json:"amortized"`
+	Items     []CostBucket `json:"items"`
 }
 
 // список всех подписок
 type SubscriptionList struct {
 	Items []Subscription `json:"items"`
-	Total int            `json:"total"`
+	// общее число подписок по фильтру; в режиме курсора не считается
+	Total *int `json:"total,omitempty"`
+	// курсор следующей страницы; пустой, если страниц больше нет
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NormalizeLabel приводит категорию или тег к виду для хранения и сравнения:
+// нижний регистр, без пробелов по краям, одиночные пробелы между словами.
+func NormalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// NormalizeTags нормализует теги, убирает пустые и повторы и сортирует их.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeLabel(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// результат окончательного удаления подписок, удалённых раньше Before
+type PurgeResult struct {
+	Purged int       `json:"purged"`
+	Before time.Time `json:"before"`
 }
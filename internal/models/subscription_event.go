@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Типы событий журнала изменений подписки
+const (
+	EventCreated      = "created"
+	EventUpdated      = "updated"
+	EventDeleted      = "deleted"
+	EventRestored     = "restored"
+	EventPurged       = "purged"
+	EventPriceAdded   = "price_added"
+	EventPriceRemoved = "price_removed"
+)
+
+// FieldChange значение поля до и после изменения; null — поля не было.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// SubscriptionEvent запись журнала изменений подписки.
+// Изменения цены с месяца записываются под ключом prices.MM-YYYY.
+type SubscriptionEvent struct {
+	ID             int64                  `json:"id"`
+	SubscriptionID string                 `json:"subscription_id"`
+	Type           string                 `json:"type" enums:"created,updated,deleted,restored,purged,price_added,price_removed"`
+	Version        int                    `json:"version"` // версия подписки после изменения, для purged — последняя
+	Actor          string                 `json:"actor,omitempty"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	Changes        map[string]FieldChange `json:"changes"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// история изменений подписки, от старых к новым
+type SubscriptionHistory struct {
+	Items []SubscriptionEvent `json:"items"`
+}
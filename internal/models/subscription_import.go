@@ -0,0 +1,33 @@
+package models
+
+// CSVTagSeparator разделяет теги в одной ячейке CSV, чтобы не путать их с разделителем колонок
+const CSVTagSeparator = "|"
+
+// SubscriptionImportColumns поля подписки, которые можно загрузить из CSV;
+// по умолчанию колонка файла называется так же, как поле
+var SubscriptionImportColumns = []string{
+	"service_id", "service_name", "price", "user_id", "start_date", "end_date",
+	"billing_period", "billing_interval", "currency", "category", "tags",
+}
+
+// SubscriptionImportRow строка CSV-импорта: значения полей как в файле, разбираются при проверке
+type SubscriptionImportRow struct {
+	Line   int               // номер строки в файле, заголовок — строка 1
+	Values map[string]string // поле из SubscriptionImportColumns → значение
+}
+
+// SubscriptionImportResult итог импорта: при dry_run ничего не сохраняется,
+// без dry_run подписки сохраняются, только если все строки корректны
+type SubscriptionImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`     // строк с данными
+	Valid    int              `json:"valid"`    // строк без ошибок
+	Imported int              `json:"imported"` // сохранённых подписок
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError ошибка строки файла в том же виде, что и ответ на POST /subscriptions
+type ImportRowError struct {
+	Row   int     `json:"row" example:"3"`
+	Error Problem `json:"error"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// SubscriptionPrice цена подписки, действующая с месяца EffectiveFrom до следующего изменения.
+// Первая запись истории — цена подписки с её start_date.
+type SubscriptionPrice struct {
+	SubscriptionID string    `json:"subscription_id"`
+	EffectiveFrom  string    `json:"effective_from"`
+	Price          Money     `json:"price" swaggertype:"string" example:"499.00"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// для добавления изменения цены
+type SubscriptionPriceInput struct {
+	EffectiveFrom string `json:"effective_from" validate:"required,month_year"`
+	Price         Money  `json:"price" validate:"required,gt=0" swaggertype:"string" example:"499.00"`
+}
+
+// история цен подписки по возрастанию effective_from
+type SubscriptionPriceList struct {
+	Items []SubscriptionPrice `json:"items"`
+}
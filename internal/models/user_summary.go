@@ -0,0 +1,17 @@
+package models
+
+// UserSummary сводка по подпискам пользователя на текущий месяц; суммы — в валюте Currency
+type UserSummary struct {
+	UserID              string `json:"user_id"`
+	Month               string `json:"month" example:"07-2025"` // текущий месяц, MM-YYYY
+	Currency            string `json:"currency"`
+	ActiveSubscriptions int    `json:"active_subscriptions"`
+	// расходы в текущем месяце: каждое списание распределено по месяцам, которые оно оплачивает
+	MonthlySpend Money `json:"monthly_spend" swaggertype:"string" example:"1299.80"`
+	// списания с января по текущий месяц включительно
+	YearSpend Money `json:"year_spend" swaggertype:"string" example:"7798.80"`
+	// сервис с наибольшими расходами в месяц, нет без активных подписок
+	MostExpensiveService *CostBucket `json:"most_expensive_service,omitempty"`
+	// активные подписки, которые заканчиваются в этом или следующем месяце
+	EndingSoon []Subscription `json:"ending_soon"`
+}
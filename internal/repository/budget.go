@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// BudgetStore бюджеты пользователей и предупреждения об их превышении.
+type BudgetStore interface {
+	Get(ctx context.Context, userID string) (*models.Budget, error)
+	// Save создаёт или заменяет бюджет; месяц превышения сбрасывается до следующей проверки
+	Save(ctx context.Context, userID string, input models.BudgetInput) (*models.Budget, error)
+	Delete(ctx context.Context, userID string) error
+	// SetExceeded запоминает результат проверки бюджета за месяц alert.Month. Если бюджет в этом месяце
+	// только что оказался превышен, в той же транзакции сохраняет alert и возвращает его; иначе возвращает nil.
+	// Превышение в другом месяце не считается: в новом месяце переход через бюджет снова создаёт предупреждение
+	SetExceeded(ctx context.Context, userID string, exceeded bool, alert models.BudgetAlert) (*models.BudgetAlert, error)
+	// ListAlerts предупреждения пользователя от новых к старым; доступны и после удаления бюджета
+	ListAlerts(ctx context.Context, userID string) ([]models.BudgetAlert, error)
+}
+
+var (
+	_ BudgetStore = (*BudgetRepository)(nil)
+	_ BudgetStore = (*MemoryBudgetRepository)(nil)
+)
+
+const budgetColumns = `user_id, amount, currency, webhook_url, exceeded_month, created_at, updated_at`
+
+type BudgetRepository struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewBudgetRepository(db *pgxpool.Pool, logger *zap.Logger) *BudgetRepository {
+	return &BudgetRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanBudget(row pgx.Row) (*models.Budget, error) {
+	var b models.Budget
+	var exceededMonth *time.Time
+	if err := row.Scan(&b.UserID, &b.Amount, &b.Currency, &b.WebhookURL, &exceededMonth, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if exceededMonth != nil {
+		b.ExceededMonth = validation.FormatMonthYear(*exceededMonth)
+	}
+	return &b, nil
+}
+
+func (r *BudgetRepository) Get(ctx context.Context, userID string) (*models.Budget, error) {
+	if uuid.Validate(userID) != nil {
+		return nil, ErrBudgetNotFound
+	}
+
+	budget, err := scanBudget(r.db.QueryRow(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE user_id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrBudgetNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get budget", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (r *BudgetRepository) Save(ctx context.Context, userID string, input models.BudgetInput) (*models.Budget, error) {
+	query := `
+		INSERT INTO budgets (user_id, amount, currency, webhook_url, exceeded_month, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULL, $5, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			amount = EXCLUDED.amount, currency = EXCLUDED.currency, webhook_url = EXCLUDED.webhook_url,
+			exceeded_month = NULL, updated_at = EXCLUDED.updated_at
+		RETURNING ` + budgetColumns
+
+	budget, err := scanBudget(r.db.QueryRow(ctx, query, userID, int64(input.Amount), input.Currency, input.WebhookURL, time.Now()))
+	if err != nil {
+		r.logger.Error("failed to save budget", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+
+	r.logger.Info("budget saved", zap.String("user_id", userID))
+	return budget, nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, userID string) error {
+	if uuid.Validate(userID) != nil {
+		return ErrBudgetNotFound
+	}
+
+	result, err := r.db.Exec(ctx, "DELETE FROM budgets WHERE user_id = $1", userID)
+	if err != nil {
+		r.logger.Error("failed to delete budget", zap.Error(err), zap.String("user_id", userID))
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrBudgetNotFound
+	}
+
+	r.logger.Info("budget deleted", zap.String("user_id", userID))
+	return nil
+}
+
+func (r *BudgetRepository) SetExceeded(ctx context.Context, userID string, exceeded bool, alert models.BudgetAlert) (*models.BudgetAlert, error) {
+	month, err := validation.ParseMonthYear(alert.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exceededMonth *time.Time // NULL — бюджет не превышен
+	if exceeded {
+		exceededMonth = &month
+	}
+	// условие на прежнее значение: из параллельных проверок переход зафиксирует только одна
+	result, err := tx.Exec(ctx, "UPDATE budgets SET exceeded_month = $2 WHERE user_id = $1 AND exceeded_month IS DISTINCT FROM $2", userID, exceededMonth)
+	if err != nil {
+		r.logger.Error("failed to update budget state", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	if result.RowsAffected() == 0 || !exceeded {
+		return nil, tx.Commit(ctx)
+	}
+
+	var subscriptionID *string
+	if alert.SubscriptionID != "" {
+		subscriptionID = &alert.SubscriptionID
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO budget_alerts (user_id, month, spend, amount, currency, subscription_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, userID, month, int64(alert.Spend), int64(alert.Amount), alert.Currency, subscriptionID, time.Now()).Scan(&alert.ID, &alert.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to save budget alert", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	alert.UserID = userID
+	return &alert, nil
+}
+
+func (r *BudgetRepository) ListAlerts(ctx context.Context, userID string) ([]models.BudgetAlert, error) {
+	alerts := []models.BudgetAlert{}
+	if uuid.Validate(userID) != nil {
+		return alerts, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, month, spend, amount, currency, subscription_id, created_at
+		FROM budget_alerts WHERE user_id = $1 ORDER BY id DESC
+	`, userID)
+	if err != nil {
+		r.logger.Error("failed to get budget alerts", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alert models.BudgetAlert
+		var month time.Time
+		var subscriptionID *string
+		if err := rows.Scan(&alert.ID, &alert.UserID, &month, &alert.Spend, &alert.Amount, &alert.Currency, &subscriptionID, &alert.CreatedAt); err != nil {
+			return nil, err
+		}
+		alert.Month = validation.FormatMonthYear(month)
+		if subscriptionID != nil {
+			alert.SubscriptionID = *subscriptionID
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get budget alerts", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	return alerts, nil
+}
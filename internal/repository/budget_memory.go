@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+// MemoryBudgetRepository хранит бюджеты и предупреждения в памяти процесса.
+type MemoryBudgetRepository struct {
+	mu      sync.RWMutex
+	budgets map[string]models.Budget // ключ — user_id
+	alerts  []models.BudgetAlert     // в порядке создания
+}
+
+func NewMemoryBudgetRepository() *MemoryBudgetRepository {
+	return &MemoryBudgetRepository{
+		budgets: make(map[string]models.Budget),
+	}
+}
+
+func (r *MemoryBudgetRepository) Get(ctx context.Context, userID string) (*models.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	budget, ok := r.budgets[userID]
+	if !ok {
+		return nil, ErrBudgetNotFound
+	}
+	return &budget, nil
+}
+
+func (r *MemoryBudgetRepository) Save(ctx context.Context, userID string, input models.BudgetInput) (*models.Budget, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	budget, ok := r.budgets[userID]
+	if !ok {
+		budget = models.Budget{UserID: userID, CreatedAt: now}
+	}
+	budget.Amount = input.Amount
+	budget.Currency = input.Currency
+	budget.WebhookURL = input.WebhookURL
+	budget.ExceededMonth = ""
+	budget.UpdatedAt = now
+	r.budgets[userID] = budget
+	return &budget, nil
+}
+
+func (r *MemoryBudgetRepository) Delete(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.budgets[userID]; !ok {
+		return ErrBudgetNotFound
+	}
+	delete(r.budgets, userID)
+	return nil
+}
+
+func (r *MemoryBudgetRepository) SetExceeded(ctx context.Context, userID string, exceeded bool, alert models.BudgetAlert) (*models.BudgetAlert, error) {
+	if _, err := validation.ParseMonthYear(alert.Month); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exceededMonth := "" // бюджет не превышен
+	if exceeded {
+		exceededMonth = alert.Month
+	}
+	budget, ok := r.budgets[userID]
+	if !ok || budget.ExceededMonth == exceededMonth {
+		return nil, nil
+	}
+	budget.ExceededMonth = exceededMonth
+	r.budgets[userID] = budget
+	if !exceeded {
+		return nil, nil
+	}
+
+	alert.ID = int64(len(r.alerts) + 1)
+	alert.UserID = userID
+	alert.CreatedAt = time.Now()
+	r.alerts = append(r.alerts, alert)
+	return &alert, nil
+}
+
+func (r *MemoryBudgetRepository) ListAlerts(ctx context.Context, userID string) ([]models.BudgetAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alerts := []models.BudgetAlert{}
+	for _, alert := range slices.Backward(r.alerts) {
+		if alert.UserID == userID {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
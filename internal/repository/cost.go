@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var ErrAmountOverflow = errors.New("amount is too large")
+
+// Группировки для разбивки стоимости
+const (
+	GroupByMonth    = "month"
+	GroupByService  = "service"
+	GroupByUser     = "user"
+	GroupByCategory = "category"
+)
+
+// monthIndex переводит дату в порядковый номер месяца, чтобы считать разницу в месяцах.
+func monthIndex(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// monthFromIndex обратное к monthIndex: первое число месяца.
+func monthFromIndex(i int) time.Time {
+	return time.Date(i/12, time.Month(i%12+1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// billedRange возвращает индексы первого и последнего месяца подписки [start, end] внутри периода
+// [periodStart, periodEnd]; ok == false, если пересечения нет. end == nil означает бессрочную подписку.
+func billedRange(start time.Time, end *time.Time, periodStart, periodEnd time.Time) (from, to int, ok bool) {
+	from = max(monthIndex(start), monthIndex(periodStart))
+	to = monthIndex(periodEnd)
+	if end != nil {
+		to = min(to, monthIndex(*end))
+	}
+	return from, to, from <= to
+}
+
+// billedMonths возвращает число месяцев подписки [start, end], попадающих в период [periodStart, periodEnd].
+// Границы включительные, end == nil означает бессрочную подписку.
+func billedMonths(start time.Time, end *time.Time, periodStart, periodEnd time.Time) int {
+	from, to, ok := billedRange(start, end, periodStart, periodEnd)
+	if !ok {
+		return 0
+	}
+	return to - from + 1
+}
+
+// costSubject данные подписки, нужные для расчёта стоимости.
+type costSubject struct {
+	ID              string
+	ServiceName     string
+	UserID          string
+	Category        string
+	Price           models.Money
+	Prices          []priceChange // изменения цены по возрастанию месяца
+	Currency        string
+	BillingPeriod   string
+	BillingInterval int
+	Start           time.Time
+	End             *time.Time
+}
+
+// billingCycle длина периода оплаты: либо в месяцах, либо в неделях (для week).
+func billingCycle(period string, interval int) (months, weeks int) {
+	interval = max(interval, 1)
+	switch period {
+	case models.BillingPeriodWeek:
+		return 0, interval
+	case models.BillingPeriodQuarter:
+		return 3 * interval, 0
+	case models.BillingPeriodYear:
+		return 12 * interval, 0
+	default:
+		return interval, 0
+	}
+}
+
+// paymentsIn число списаний в месяце i. Первое списание — в месяц start_date,
+// далее каждые months месяцев или каждые 7*weeks дней от первого числа этого месяца.
+func (s costSubject) paymentsIn(i int) int {
+	months, weeks := billingCycle(s.BillingPeriod, s.BillingInterval)
+	if weeks == 0 {
+		d := i - monthIndex(s.Start)
+		if d >= 0 && d%months == 0 {
+			return 1
+		}
+		return 0
+	}
+
+	step := 7 * weeks
+	from := daysBetween(s.Start, monthFromIndex(i))
+	to := daysBetween(s.Start, monthFromIndex(i+1))
+	if to <= 0 {
+		return 0
+	}
+	first := ceilDiv(max(from, 0), step)
+	last := ceilDiv(to, step) - 1
+	return max(last-first+1, 0)
+}
+
+// priceChange цена, действующая с месяца month (monthIndex).
+type priceChange struct {
+	month int
+	price models.Money
+}
+
+// priceAt цена в месяце i: последнее изменение не позже этого месяца, иначе исходная цена.
+func (s costSubject) priceAt(i int) models.Money {
+	price := s.Price
+	for _, c := range s.Prices {
+		if c.month > i {
+			break
+		}
+		price = c.price
+	}
+	return price
+}
+
+// amortizedShare доля суммы цен за месяцы при равномерном распределении списаний, с округлением.
+// Считается от накопленной суммы, чтобы помесячные значения после округления сходились с итогом.
+func (s costSubject) amortizedShare(priceSum *big.Int) *big.Int {
+	months, weeks := billingCycle(s.BillingPeriod, s.BillingInterval)
+	num, den := int64(1), int64(months)
+	if weeks != 0 {
+		num, den = 52, int64(12*weeks) // в году 52 недели
+	}
+	v := new(big.Int).Mul(priceSum, big.NewInt(num))
+	v.Add(v, big.NewInt(den/2))
+	return v.Quo(v, big.NewInt(den))
+}
+
+// addMoney складывает неотрицательные суммы с проверкой переполнения int64.
+func addMoney(a, b models.Money) (models.Money, error) {
+	if b > 0 && a > math.MaxInt64-b {
+		return 0, ErrAmountOverflow
+	}
+	return a + b, nil
+}
+
+// moneyFromInt переводит точное значение в Money, если оно помещается в int64.
+func moneyFromInt(v *big.Int) (models.Money, error) {
+	if !v.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return models.Money(v.Int64()), nil
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// billedSubject подписка, активная в периоде, с диапазоном оплачиваемых месяцев.
+type billedSubject struct {
+	costSubject
+	from, to int
+	cost     models.Money
+	monthly  []models.Money // стоимость по месяцам from..to в валюте расчёта
+}
+
+// costCalculator накапливает стоимость подписок за период; общий для всех реализаций SubscriptionStore.
+type costCalculator struct {
+	periodStart time.Time
+	periodEnd   time.Time
+	amortize    bool
+	currency    string
+	rates       rateTable
+	response    *models.TotalCostResponse
+	billed      []billedSubject
+}
+
+func newCostCalculator(params models.CostParams) (*costCalculator, error) {
+	periodStart, err := validation.ParseMonthYear(params.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	periodEnd, err := validation.ParseMonthYear(params.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := newRateTable(params.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := params.Currency
+	if currency == "" {
+		currency = models.BaseCurrency
+	}
+
+	return &costCalculator{
+		periodStart: periodStart,
+		periodEnd:   periodEnd,
+		amortize:    params.Amortize,
+		currency:    currency,
+		rates:       rates,
+		response: &models.TotalCostResponse{
+			Currency:  currency,
+			Amortized: params.Amortize,
+			Items:     []models.SubscriptionCost{},
+		},
+	}, nil
+}
+
+func subjectCurrency(s costSubject) string {
+	if s.Currency == "" {
+		return models.BaseCurrency
+	}
+	return s.Currency
+}
+
+// add учитывает подписку, если она активна хотя бы один месяц периода. Каждый месяц считается
+// по действующей в нём цене: списания этого месяца или амортизированная доля, переведённые в валюту
+// расчёта по курсу этого месяца. Промежуточные значения считаются без ограничения разрядности.
+// Стоимость по месяцам сохраняется, чтобы разбивка не пересчитывала курсы.
+func (c *costCalculator) add(s costSubject) error {
+	from, to, ok := billedRange(s.Start, s.End, c.periodStart, c.periodEnd)
+	if !ok {
+		return nil
+	}
+	billed := billedSubject{costSubject: s, from: from, to: to}
+
+	item := models.SubscriptionCost{
+		SubscriptionID:  s.ID,
+		ServiceName:     s.ServiceName,
+		Price:           s.priceAt(to),
+		Currency:        subjectCurrency(s),
+		BillingPeriod:   s.BillingPeriod,
+		BillingInterval: s.BillingInterval,
+		Months:          to - from + 1,
+	}
+
+	priceSum := new(big.Int)
+	shared := new(big.Int)
+	for i := from; i <= to; i++ {
+		price := big.NewInt(int64(s.priceAt(i)))
+
+		var amount *big.Int
+		if c.amortize {
+			priceSum.Add(priceSum, price)
+			next := s.amortizedShare(priceSum)
+			amount = new(big.Int).Sub(next, shared)
+			shared = next
+		} else {
+			payments := s.paymentsIn(i)
+			item.Payments += payments
+			amount = price.Mul(price, big.NewInt(int64(payments)))
+		}
+
+		cost, err := c.rates.convert(amount, subjectCurrency(s), c.currency, i)
+		if err != nil {
+			return err
+		}
+		billed.monthly = append(billed.monthly, cost)
+		if item.Cost, err = addMoney(item.Cost, cost); err != nil {
+			return err
+		}
+	}
+	billed.cost = item.Cost
+
+	total, err := addMoney(c.response.TotalCost, item.Cost)
+	if err != nil {
+		return err
+	}
+
+	c.response.Items = append(c.response.Items, item)
+	c.response.TotalCost = total
+	c.response.Count++
+	c.billed = append(c.billed, billed)
+	return nil
+}
+
+// breakdown группирует накопленную стоимость. Для группировки по месяцам в ответ попадают
+// все месяцы периода, включая нулевые, в хронологическом порядке; для остальных — по убыванию суммы.
+// Суммы групп не превышают итог, поэтому переполнение уже проверено в add.
+func (c *costCalculator) breakdown(groupBy string) *models.CostBreakdownResponse {
+	buckets := make(map[string]*models.CostBucket)
+	var keys []string
+	bucket := func(key string) *models.CostBucket {
+		b, ok := buckets[key]
+		if !ok {
+			b = &models.CostBucket{Key: key}
+			buckets[key] = b
+			keys = append(keys, key)
+		}
+		return b
+	}
+
+	if groupBy == GroupByMonth {
+		for i := monthIndex(c.periodStart); i <= monthIndex(c.periodEnd); i++ {
+			bucket(validation.FormatMonthYear(monthFromIndex(i)))
+		}
+	}
+
+	for _, s := range c.billed {
+		switch groupBy {
+		case GroupByMonth:
+			for i := s.from; i <= s.to; i++ {
+				b := bucket(validation.FormatMonthYear(monthFromIndex(i)))
+				b.Amount += s.monthly[i-s.from]
+				b.Count++
+			}
+		default:
+			key := s.ServiceName
+			switch groupBy {
+			case GroupByUser:
+				key = s.UserID
+			case GroupByCategory:
+				key = s.Category
+			}
+			b := bucket(key)
+			b.Amount += s.cost
+			b.Count++
+		}
+	}
+
+	items := make([]models.CostBucket, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, *buckets[key])
+	}
+	if groupBy != GroupByMonth {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Amount != items[j].Amount {
+				return items[i].Amount > items[j].Amount
+			}
+			return items[i].Key < items[j].Key
+		})
+	}
+
+	return &models.CostBreakdownResponse{
+		GroupBy:   groupBy,
+		StartDate: validation.FormatMonthYear(c.periodStart),
+		EndDate:   validation.FormatMonthYear(c.periodEnd),
+		TotalCost: c.response.TotalCost,
+		Currency:  c.currency,
+		Amortized: c.amortize,
+		Items:     items,
+	}
+}
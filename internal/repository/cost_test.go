@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"em-internship/internal/models"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBilledMonths(t *testing.T) {
+	periodStart := month(2025, time.January)
+	periodEnd := month(2025, time.December)
+	dec2024 := month(2024, time.December)
+	mar2025 := month(2025, time.March)
+	feb2026 := month(2026, time.February)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   *time.Time
+		want  int
+	}{
+		{"open-ended before period", dec2024, nil, 12},
+		{"open-ended inside period", mar2025, nil, 10},
+		{"ends inside period", dec2024, &mar2025, 3},
+		{"starts and ends inside period", mar2025, &mar2025, 1},
+		{"ends before period", month(2024, time.January), &dec2024, 0},
+		{"starts after period", feb2026, nil, 0},
+		{"covers whole period", dec2024, &feb2026, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := billedMonths(tt.start, tt.end, periodStart, periodEnd); got != tt.want {
+				t.Errorf("billedMonths() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCostCalculatorBreakdown(t *testing.T) {
+	calc, err := newCostCalculator(models.CostParams{StartDate: "01-2025", EndDate: "04-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feb2025 := month(2025, time.February)
+	calc.add(costSubject{ID: "a", ServiceName: "Netflix", UserID: "u1", Category: "streaming", Price: 100, Start: month(2024, time.June)})
+	calc.add(costSubject{ID: "b", ServiceName: "Spotify", UserID: "u2", Category: "music", Price: 300, Start: month(2024, time.June), End: &feb2025})
+	calc.add(costSubject{ID: "c", ServiceName: "Netflix", UserID: "u2", Price: 50, Start: month(2025, time.May)})
+
+	byMonth := calc.breakdown(GroupByMonth)
+	wantMonths := []struct {
+		key    string
+		amount models.Money
+		count  int
+	}{{"01-2025", 400, 2}, {"02-2025", 400, 2}, {"03-2025", 100, 1}, {"04-2025", 100, 1}}
+	if len(byMonth.Items) != len(wantMonths) {
+		t.Fatalf("month buckets = %d, want %d", len(byMonth.Items), len(wantMonths))
+	}
+	for i, want := range wantMonths {
+		got := byMonth.Items[i]
+		if got.Key != want.key || got.Amount != want.amount || got.Count != want.count {
+			t.Errorf("month bucket %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if byMonth.TotalCost != 1000 {
+		t.Errorf("TotalCost = %d, want 1000", byMonth.TotalCost)
+	}
+
+	byService := calc.breakdown(GroupByService)
+	if len(byService.Items) != 2 || byService.Items[0].Key != "Spotify" || byService.Items[0].Amount != 600 || byService.Items[1].Amount != 400 {
+		t.Errorf("service buckets = %+v", byService.Items)
+	}
+
+	byUser := calc.breakdown(GroupByUser)
+	if len(byUser.Items) != 2 || byUser.Items[0].Key != "u2" || byUser.Items[0].Count != 1 {
+		t.Errorf("user buckets = %+v", byUser.Items)
+	}
+
+	byCategory := calc.breakdown(GroupByCategory)
+	if len(byCategory.Items) != 2 || byCategory.Items[0].Key != "music" || byCategory.Items[1].Key != "streaming" || byCategory.Items[1].Amount != 400 {
+		t.Errorf("category buckets = %+v", byCategory.Items)
+	}
+}
+
+func TestCostCalculatorBillingPeriods(t *testing.T) {
+	tests := []struct {
+		name          string
+		subject       costSubject
+		start, end    string
+		wantPayments  int
+		wantCost      models.Money
+		wantAmortized models.Money
+	}{
+		{
+			name:    "yearly charged once in anniversary month",
+			subject: costSubject{Price: 1200, BillingPeriod: models.BillingPeriodYear, BillingInterval: 1, Start: month(2024, time.March)},
+			start:   "01-2025", end: "12-2025",
+			wantPayments: 1, wantCost: 1200, wantAmortized: 1200,
+		},
+		{
+			name:    "yearly outside anniversary month",
+			subject: costSubject{Price: 1200, BillingPeriod: models.BillingPeriodYear, BillingInterval: 1, Start: month(2024, time.March)},
+			start:   "04-2025", end: "06-2025",
+			wantPayments: 0, wantCost: 0, wantAmortized: 300,
+		},
+		{
+			name:    "quarterly",
+			subject: costSubject{Price: 300, BillingPeriod: models.BillingPeriodQuarter, BillingInterval: 1, Start: month(2025, time.January)},
+			start:   "01-2025", end: "06-2025",
+			wantPayments: 2, wantCost: 600, wantAmortized: 600,
+		},
+		{
+			name:    "every two months",
+			subject: costSubject{Price: 100, BillingPeriod: models.BillingPeriodMonth, BillingInterval: 2, Start: month(2025, time.January)},
+			start:   "01-2025", end: "05-2025",
+			wantPayments: 3, wantCost: 300, wantAmortized: 250,
+		},
+		{
+			name:    "weekly",
+			subject: costSubject{Price: 10, BillingPeriod: models.BillingPeriodWeek, BillingInterval: 1, Start: month(2025, time.January)},
+			start:   "01-2025", end: "01-2025",
+			wantPayments: 5, wantCost: 50, wantAmortized: 43,
+		},
+		{
+			name:    "defaults to monthly",
+			subject: costSubject{Price: 100, Start: month(2025, time.January)},
+			start:   "01-2025", end: "03-2025",
+			wantPayments: 3, wantCost: 300, wantAmortized: 300,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, amortize := range []bool{false, true} {
+				calc, err := newCostCalculator(models.CostParams{StartDate: tt.start, EndDate: tt.end, Amortize: amortize})
+				if err != nil {
+					t.Fatal(err)
+				}
+				calc.add(tt.subject)
+
+				var got models.SubscriptionCost
+				if len(calc.response.Items) == 1 {
+					got = calc.response.Items[0]
+				}
+				want := tt.wantCost
+				if amortize {
+					want = tt.wantAmortized
+				}
+				if got.Cost != want {
+					t.Errorf("amortize=%v: cost = %d, want %d", amortize, got.Cost, want)
+				}
+				if !amortize && got.Payments != tt.wantPayments {
+					t.Errorf("payments = %d, want %d", got.Payments, tt.wantPayments)
+				}
+
+				// помесячная разбивка сходится с итогом и после округления
+				var sum models.Money
+				for _, b := range calc.breakdown(GroupByMonth).Items {
+					sum += b.Amount
+				}
+				if sum != got.Cost {
+					t.Errorf("amortize=%v: month breakdown sum = %d, want %d", amortize, sum, got.Cost)
+				}
+			}
+		})
+	}
+}
+
+func TestCostCalculatorCurrency(t *testing.T) {
+	rates := []models.ExchangeRate{
+		{Currency: "USD", EffectiveFrom: "01-2025", Rate: "90"},
+		{Currency: "USD", EffectiveFrom: "03-2025", Rate: "100.5"},
+		{Currency: "EUR", EffectiveFrom: "01-2025", Rate: "100"},
+	}
+	calc, err := newCostCalculator(models.CostParams{StartDate: "01-2025", EndDate: "03-2025", Currency: "RUB", Rates: rates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.add(costSubject{ID: "a", Price: 10, Currency: "USD", Start: month(2025, time.January)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.add(costSubject{ID: "b", Price: 500, Start: month(2025, time.February)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 USD по 90 в январе и феврале, по 100.5 в марте; рубли без пересчёта
+	if got := calc.response.Items[0].Cost; got != 900+900+1005 {
+		t.Errorf("USD subscription cost = %d, want %d", got, 900+900+1005)
+	}
+	if got := calc.response.TotalCost; got != 2805+1000 {
+		t.Errorf("TotalCost = %d, want %d", got, 2805+1000)
+	}
+
+	toEUR, err := newCostCalculator(models.CostParams{StartDate: "03-2025", EndDate: "03-2025", Currency: "EUR", Rates: rates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := toEUR.add(costSubject{ID: "a", Price: 10, Currency: "USD", Start: month(2025, time.January)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := toEUR.response.TotalCost; got != 10 { // 10 × 100.5 / 100 = 10.05
+		t.Errorf("EUR total = %d, want 10", got)
+	}
+
+	early, err := newCostCalculator(models.CostParams{StartDate: "12-2024", EndDate: "01-2025", Rates: rates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = early.add(costSubject{ID: "a", Price: 10, Currency: "USD", Start: month(2024, time.December)})
+	if !errors.Is(err, ErrMissingExchangeRate) {
+		t.Errorf("expected ErrMissingExchangeRate, got %v", err)
+	}
+}
+
+func TestCostCalculatorOverflow(t *testing.T) {
+	calc, err := newCostCalculator(models.CostParams{StartDate: "01-2025", EndDate: "12-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	huge := models.Money(math.MaxInt64 / 10)
+	if err := calc.add(costSubject{ID: "a", Price: huge, Start: month(2025, time.January)}); !errors.Is(err, ErrAmountOverflow) {
+		t.Errorf("expected ErrAmountOverflow, got %v", err)
+	}
+
+	amortized, err := newCostCalculator(models.CostParams{StartDate: "01-2025", EndDate: "12-2025", Amortize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// промежуточное price × months не помещается в int64, но итог — помещается
+	price := models.Money(math.MaxInt64 / 6)
+	if err := amortized.add(costSubject{ID: "a", Price: price, BillingPeriod: models.BillingPeriodYear, Start: month(2025, time.January)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := amortized.response.TotalCost; got != price {
+		t.Errorf("amortized total = %d, want %d", got, price)
+	}
+}
+
+func TestCostCalculatorPriceHistory(t *testing.T) {
+	subject := costSubject{
+		ID: "a", Price: 100, Start: month(2025, time.January),
+		Prices: []priceChange{
+			{month: monthIndex(month(2025, time.March)), price: 150},
+			{month: monthIndex(month(2025, time.May)), price: 200},
+		},
+	}
+
+	calc, err := newCostCalculator(models.CostParams{StartDate: "02-2025", EndDate: "05-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.add(subject); err != nil {
+		t.Fatal(err)
+	}
+	// февраль по старой цене, март и апрель по 150, май по 200
+	if got := calc.response.TotalCost; got != 100+150+150+200 {
+		t.Errorf("TotalCost = %d, want %d", got, 100+150+150+200)
+	}
+	if got := calc.response.Items[0].Price; got != 200 {
+		t.Errorf("Price = %d, want price effective at the end of the period", got)
+	}
+
+	yearly := subject
+	yearly.BillingPeriod = models.BillingPeriodQuarter
+	amortized, err := newCostCalculator(models.CostParams{StartDate: "01-2025", EndDate: "06-2025", Amortize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := amortized.add(yearly); err != nil {
+		t.Fatal(err)
+	}
+	// (100 + 100 + 150 + 150 + 200 + 200) / 3
+	if got := amortized.response.TotalCost; got != 300 {
+		t.Errorf("amortized TotalCost = %d, want 300", got)
+	}
+}
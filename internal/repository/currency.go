@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var ErrMissingExchangeRate = errors.New("no exchange rate")
+
+// ratePoint курс, действующий начиная с месяца month (monthIndex).
+type ratePoint struct {
+	month int
+	rate  *big.Rat
+}
+
+// rateTable курсы валют к models.BaseCurrency по месяцам.
+type rateTable map[string][]ratePoint
+
+func newRateTable(rates []models.ExchangeRate) (rateTable, error) {
+	table := make(rateTable)
+	for _, r := range rates {
+		month, err := validation.ParseMonthYear(r.EffectiveFrom)
+		if err != nil {
+			return nil, err
+		}
+		rate, ok := new(big.Rat).SetString(r.Rate)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid exchange rate %q for %s", r.Rate, r.Currency)
+		}
+		table[r.Currency] = append(table[r.Currency], ratePoint{month: monthIndex(month), rate: rate})
+	}
+	for _, points := range table {
+		sort.Slice(points, func(i, j int) bool { return points[i].month < points[j].month })
+	}
+	return table, nil
+}
+
+// rate курс валюты, действующий в месяце i: последний курс с effective_from не позже этого месяца.
+func (t rateTable) rate(currency string, i int) (*big.Rat, error) {
+	if currency == models.BaseCurrency {
+		return big.NewRat(1, 1), nil
+	}
+
+	points := t[currency]
+	n := sort.Search(len(points), func(k int) bool { return points[k].month > i })
+	if n == 0 {
+		return nil, fmt.Errorf("%w for %s in %s", ErrMissingExchangeRate, currency, validation.FormatMonthYear(monthFromIndex(i)))
+	}
+	return points[n-1].rate, nil
+}
+
+// convert переводит сумму в минимальных единицах из валюты from в валюту to по курсам месяца i
+// с округлением до минимальной единицы.
+func (t rateTable) convert(amount *big.Int, from, to string, i int) (models.Money, error) {
+	if amount.Sign() == 0 || from == to {
+		return moneyFromInt(amount)
+	}
+
+	fromRate, err := t.rate(from, i)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := t.rate(to, i)
+	if err != nil {
+		return 0, err
+	}
+
+	v := new(big.Rat).SetInt(amount)
+	v.Mul(v, fromRate)
+	v.Quo(v, toRate)
+	return moneyFromInt(roundRat(v))
+}
+
+// roundRat округляет неотрицательное число до ближайшего целого, половину — вверх.
+func roundRat(v *big.Rat) *big.Int {
+	num := new(big.Int).Mul(v.Num(), big.NewInt(2))
+	num.Add(num, v.Denom())
+	den := new(big.Int).Mul(v.Denom(), big.NewInt(2))
+	return num.Quo(num, den)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"em-internship/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// subscriptionCursor позиция в списке для keyset-пагинации по (created_at, id).
+// Клиенту отдаётся в виде непрозрачной base64-строки.
+type subscriptionCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+func encodeCursor(c subscriptionCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор; пустая строка означает первую страницу (nil).
+func decodeCursor(s string) (*subscriptionCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c subscriptionCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// cursorDirection возвращает направление обхода для keyset-пагинации: поддерживается только сортировка по created_at.
+func cursorDirection(sort string) (desc bool, err error) {
+	field, desc, err := parseSort(sort)
+	if err != nil {
+		return false, err
+	}
+	if field != "created_at" {
+		return false, ErrInvalidCursor
+	}
+	return desc, nil
+}
+
+// cursorPage обрезает выборку из limit+1 строк до страницы и выставляет курсор следующей.
+func cursorPage(subs []models.Subscription, limit int) *models.SubscriptionList {
+	list := &models.SubscriptionList{Items: subs}
+	if limit > 0 && len(subs) > limit {
+		list.Items = subs[:limit]
+		last := list.Items[limit-1]
+		list.NextCursor = encodeCursor(subscriptionCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	return list
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"em-internship/internal/audit"
+	"em-internship/internal/models"
+)
+
+// eventSkippedFields меняются при каждом изменении и в журнал не попадают; версия хранится в самом событии
+var eventSkippedFields = []string{"version", "created_at", "updated_at"}
+
+// subscriptionFields подписка в виде полей JSON API; nil — пустой набор полей.
+func subscriptionFields(sub *models.Subscription) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if sub == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range eventSkippedFields {
+		delete(fields, key)
+	}
+	return fields, nil
+}
+
+// diffSubscriptions изменённые поля подписки; before == nil — создание, after == nil — удаление.
+func diffSubscriptions(before, after *models.Subscription) (map[string]models.FieldChange, error) {
+	old, err := subscriptionFields(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := subscriptionFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.FieldChange)
+	for key, value := range current {
+		if prev, ok := old[key]; !ok || !reflect.DeepEqual(prev, value) {
+			changes[key] = models.FieldChange{Before: old[key], After: value}
+		}
+	}
+	for key, value := range old {
+		if _, ok := current[key]; !ok {
+			changes[key] = models.FieldChange{Before: value}
+		}
+	}
+	return changes, nil
+}
+
+// newSubscriptionEvent событие изменения подписки с автором и запросом из контекста.
+func newSubscriptionEvent(ctx context.Context, eventType string, before, after *models.Subscription) (models.SubscriptionEvent, error) {
+	changes, err := diffSubscriptions(before, after)
+	if err != nil {
+		return models.SubscriptionEvent{}, err
+	}
+
+	sub := after
+	if sub == nil {
+		sub = before
+	}
+	info := audit.FromContext(ctx)
+	return models.SubscriptionEvent{
+		SubscriptionID: sub.ID,
+		Type:           eventType,
+		Version:        sub.Version,
+		Actor:          info.Actor,
+		RequestID:      info.RequestID,
+		Changes:        changes,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// newPriceEvent событие добавления или удаления цены с месяца effectiveFrom.
+func newPriceEvent(ctx context.Context, eventType string, subscriptionID string, version int, effectiveFrom string, before, after *models.Money) models.SubscriptionEvent {
+	change := models.FieldChange{}
+	if before != nil {
+		change.Before = before.String()
+	}
+	if after != nil {
+		change.After = after.String()
+	}
+
+	info := audit.FromContext(ctx)
+	return models.SubscriptionEvent{
+		SubscriptionID: subscriptionID,
+		Type:           eventType,
+		Version:        version,
+		Actor:          info.Actor,
+		RequestID:      info.RequestID,
+		Changes:        map[string]models.FieldChange{"prices." + effectiveFrom: change},
+		CreatedAt:      time.Now(),
+	}
+}
+
+// insertEvent записывает событие в транзакции изменения, чтобы журнал не расходился с данными.
+func insertEvent(ctx context.Context, tx pgx.Tx, event models.SubscriptionEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_events (subscription_id, event_type, version, actor, request_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = tx.Exec(ctx, query,
+		event.SubscriptionID, event.Type, event.Version, event.Actor, event.RequestID, changes, event.CreatedAt,
+	)
+	return err
+}
+
+// ListEvents возвращает журнал изменений подписки от старых событий к новым.
+// История удалённой подписки остаётся доступной.
+func (r *SubscriptionRepository) ListEvents(ctx context.Context, subscriptionID string) ([]models.SubscriptionEvent, error) {
+	if uuid.Validate(subscriptionID) != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	query := `
+		SELECT id, subscription_id, event_type, version, actor, request_id, changes, created_at
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, query, subscriptionID)
+	if err != nil {
+		r.logger.Error("failed to get subscription events", zap.Error(err), zap.String("id", subscriptionID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.SubscriptionEvent{}
+	for rows.Next() {
+		var event models.SubscriptionEvent
+		var changes []byte
+		if err := rows.Scan(&event.ID, &event.SubscriptionID, &event.Type, &event.Version, &event.Actor, &event.RequestID, &changes, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get subscription events", zap.Error(err), zap.String("id", subscriptionID))
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		// подписки, созданные до журнала, событий не имеют
+		if _, err := r.GetByID(ctx, subscriptionID); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+// ExchangeRateStore хранилище курсов валют к models.BaseCurrency.
+type ExchangeRateStore interface {
+	// List возвращает курсы по валюте и дате начала действия; пустая currency — все валюты.
+	List(ctx context.Context, currency string) ([]models.ExchangeRate, error)
+	// ListForPeriod курсы валют currencies, нужные для расчёта за месяцы [start, end]: курс, действующий в start,
+	// и курсы, вступающие в силу до end включительно. Порядок тот же, что у List.
+	ListForPeriod(ctx context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error)
+	// Upsert создаёт или заменяет курсы атомарно: либо сохраняются все, либо ни одного.
+	Upsert(ctx context.Context, rates []models.ExchangeRateInput) error
+	Delete(ctx context.Context, currency, effectiveFrom string) error
+}
+
+var (
+	_ ExchangeRateStore = (*ExchangeRateRepository)(nil)
+	_ ExchangeRateStore = (*MemoryExchangeRateRepository)(nil)
+)
+
+type ExchangeRateRepository struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewExchangeRateRepository(db *pgxpool.Pool, logger *zap.Logger) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ExchangeRateRepository) List(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	// rate читается текстом, чтобы NUMERIC не проходил через float
+	query := `SELECT currency, effective_from, rate::text, updated_at FROM exchange_rates`
+	var args []interface{}
+	if currency != "" {
+		query += " WHERE currency = $1"
+		args = append(args, currency)
+	}
+	query += " ORDER BY currency, effective_from"
+
+	return r.queryRates(ctx, query, args...)
+}
+
+func (r *ExchangeRateRepository) ListForPeriod(ctx context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error) {
+	// более ранние курсы не нужны: в start уже действует последний из них
+	query := `
+		SELECT currency, effective_from, rate::text, updated_at FROM exchange_rates r
+		WHERE currency = ANY($1) AND effective_from <= $3 AND effective_from >= COALESCE(
+			(SELECT max(effective_from) FROM exchange_rates p WHERE p.currency = r.currency AND p.effective_from <= $2), $2)
+		ORDER BY currency, effective_from
+	`
+	return r.queryRates(ctx, query, currencies, start, end)
+}
+
+func (r *ExchangeRateRepository) queryRates(ctx context.Context, query string, args ...interface{}) ([]models.ExchangeRate, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to get exchange rates", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var rate models.ExchangeRate
+		var effectiveFrom time.Time
+		if err := rows.Scan(&rate.Currency, &effectiveFrom, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.EffectiveFrom = validation.FormatMonthYear(effectiveFrom)
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get exchange rates", zap.Error(err))
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rates []models.ExchangeRateInput) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO exchange_rates (currency, effective_from, rate, updated_at)
+		VALUES ($1, $2, $3::numeric, $4)
+		ON CONFLICT (currency, effective_from) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+	`
+
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		effectiveFrom, err := validation.ParseMonthYear(rate.EffectiveFrom)
+		if err != nil {
+			return err
+		}
+		batch.Queue(query, rate.Currency, effectiveFrom, rate.Rate, now)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		r.logger.Error("failed to save exchange rates", zap.Error(err))
+		return fmt.Errorf("failed to save exchange rates: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	r.logger.Info("saved exchange rates", zap.Int("count", len(rates)))
+	return nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, currency, effectiveFrom string) error {
+	month, err := validation.ParseMonthYear(effectiveFrom)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(ctx, "DELETE FROM exchange_rates WHERE currency = $1 AND effective_from = $2", currency, month)
+	if err != nil {
+		r.logger.Error("failed to delete exchange rate", zap.Error(err))
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrExchangeRateNotFound
+	}
+
+	r.logger.Info("exchange rate deleted", zap.String("currency", currency), zap.String("effective_from", effectiveFrom))
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+// MemoryExchangeRateRepository хранит курсы валют в памяти процесса.
+type MemoryExchangeRateRepository struct {
+	mu    sync.RWMutex
+	rates map[string]models.ExchangeRate // ключ — валюта и месяц
+}
+
+func NewMemoryExchangeRateRepository() *MemoryExchangeRateRepository {
+	return &MemoryExchangeRateRepository{
+		rates: make(map[string]models.ExchangeRate),
+	}
+}
+
+func exchangeRateKey(currency, effectiveFrom string) string {
+	return currency + "/" + effectiveFrom
+}
+
+func (r *MemoryExchangeRateRepository) List(ctx context.Context, currency string) ([]models.ExchangeRate, error) {
+	r.mu.RLock()
+	rates := make([]models.ExchangeRate, 0, len(r.rates))
+	for _, rate := range r.rates {
+		if currency == "" || rate.Currency == currency {
+			rates = append(rates, rate)
+		}
+	}
+	r.mu.RUnlock()
+
+	// тот же порядок, что и в PostgreSQL: по валюте, затем по дате
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Currency != rates[j].Currency {
+			return rates[i].Currency < rates[j].Currency
+		}
+		a, _ := validation.ParseMonthYear(rates[i].EffectiveFrom)
+		b, _ := validation.ParseMonthYear(rates[j].EffectiveFrom)
+		return a.Before(b)
+	})
+
+	return rates, nil
+}
+
+func (r *MemoryExchangeRateRepository) ListForPeriod(ctx context.Context, currencies []string, start, end time.Time) ([]models.ExchangeRate, error) {
+	all, err := r.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []models.ExchangeRate{}
+	for i, rate := range all {
+		if !slices.Contains(currencies, rate.Currency) {
+			continue
+		}
+		month, err := validation.ParseMonthYear(rate.EffectiveFrom)
+		if err != nil {
+			return nil, err
+		}
+		if month.After(end) {
+			continue
+		}
+		// курс до start нужен, только если следующий курс этой валюты вступает в силу позже start
+		if month.Before(start) && i+1 < len(all) && all[i+1].Currency == rate.Currency {
+			next, err := validation.ParseMonthYear(all[i+1].EffectiveFrom)
+			if err != nil {
+				return nil, err
+			}
+			if !next.After(start) {
+				continue
+			}
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (r *MemoryExchangeRateRepository) Upsert(ctx context.Context, rates []models.ExchangeRateInput) error {
+	for _, rate := range rates {
+		if _, err := validation.ParseMonthYear(rate.EffectiveFrom); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, rate := range rates {
+		r.rates[exchangeRateKey(rate.Currency, rate.EffectiveFrom)] = models.ExchangeRate{
+			Currency:      rate.Currency,
+			EffectiveFrom: rate.EffectiveFrom,
+			Rate:          rate.Rate,
+			UpdatedAt:     now,
+		}
+	}
+	return nil
+}
+
+func (r *MemoryExchangeRateRepository) Delete(ctx context.Context, currency, effectiveFrom string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := exchangeRateKey(currency, effectiveFrom)
+	if _, ok := r.rates[key]; !ok {
+		return ErrExchangeRateNotFound
+	}
+	delete(r.rates, key)
+	return nil
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+const defaultSubscriptionSort = "-created_at"
+
+// subscriptionSortColumns допустимые поля сортировки и соответствующие колонки
+var subscriptionSortColumns = map[string]string{
+	"price":        "price",
+	"start_date":   "start_date",
+	"service_name": "service_name",
+	"created_at":   "created_at",
+}
+
+// parseSort разбирает сортировку вида "price" или "-price" в поле и направление.
+func parseSort(sort string) (field string, desc bool, err error) {
+	if sort == "" {
+		sort = defaultSubscriptionSort
+	}
+	field = strings.TrimPrefix(sort, "-")
+	if _, ok := subscriptionSortColumns[field]; !ok {
+		return "", false, fmt.Errorf("unsupported sort field %q", field)
+	}
+	return field, strings.HasPrefix(sort, "-"), nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// buildSubscriptionWhere собирает WHERE по фильтру; позиционные параметры начинаются с $1.
+func buildSubscriptionWhere(f models.SubscriptionFilter) (string, []interface{}, error) {
+	// удалённые подписки видны только в корзине
+	conds := []string{"deleted_at IS NULL"}
+	if f.Deleted {
+		conds[0] = "deleted_at IS NOT NULL"
+	}
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.UserID != "" {
+		add("user_id = $%d", f.UserID)
+	}
+	if f.ServiceID != "" {
+		add("service_id = $%d", f.ServiceID)
+	}
+	if f.ServiceName != "" {
+		add("service_name = $%d", f.ServiceName)
+	}
+	if f.ServiceNamePrefix != "" {
+		add("lower(service_name) LIKE $%d", strings.ToLower(escapeLike(f.ServiceNamePrefix))+"%")
+	}
+	if f.MinPrice != nil {
+		add("price >= $%d", int64(*f.MinPrice))
+	}
+	if f.MaxPrice != nil {
+		add("price <= $%d", int64(*f.MaxPrice))
+	}
+	if f.ActiveIn != "" {
+		month, err := validation.ParseMonthYear(f.ActiveIn)
+		if err != nil {
+			return "", nil, err
+		}
+		add("start_date <= $%d", month)
+		conds = append(conds, fmt.Sprintf("(end_date IS NULL OR end_date >= $%d)", len(args)))
+	}
+	if f.Category != "" {
+		add("category = $%d", f.Category)
+	}
+	for _, tag := range f.Tags {
+		add("EXISTS (SELECT 1 FROM subscription_tags t WHERE t.subscription_id = subscriptions.id AND t.tag = $%d)", tag)
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// buildSubscriptionOrder собирает ORDER BY; id добавляется для стабильного порядка при равных значениях.
+func buildSubscriptionOrder(sort string) (string, error) {
+	field, desc, err := parseSort(sort)
+	if err != nil {
+		return "", err
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", subscriptionSortColumns[field], direction, direction), nil
+}
+
+// matchesFilter проверяет подписку на соответствие фильтру так же, как buildSubscriptionWhere.
+func matchesFilter(sub models.Subscription, f models.SubscriptionFilter) (bool, error) {
+	if (sub.DeletedAt != nil) != f.Deleted {
+		return false, nil
+	}
+	if f.UserID != "" && sub.UserID != f.UserID {
+		return false, nil
+	}
+	if f.ServiceID != "" && (sub.ServiceID == nil || *sub.ServiceID != f.ServiceID) {
+		return false, nil
+	}
+	if f.ServiceName != "" && sub.ServiceName != f.ServiceName {
+		return false, nil
+	}
+	if f.ServiceNamePrefix != "" && !strings.HasPrefix(strings.ToLower(sub.ServiceName), strings.ToLower(f.ServiceNamePrefix)) {
+		return false, nil
+	}
+	if f.MinPrice != nil && sub.Price < *f.MinPrice {
+		return false, nil
+	}
+	if f.MaxPrice != nil && sub.Price > *f.MaxPrice {
+		return false, nil
+	}
+	if f.ActiveIn != "" {
+		month, err := validation.ParseMonthYear(f.ActiveIn)
+		if err != nil {
+			return false, err
+		}
+		start, end, err := subscriptionPeriod(sub)
+		if err != nil {
+			return false, err
+		}
+		if billedMonths(start, end, month, month) == 0 {
+			return false, nil
+		}
+	}
+	if f.Category != "" && sub.Category != f.Category {
+		return false, nil
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(sub.Tags, tag) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// subscriptionPeriod разбирает даты подписки из формата API.
+func subscriptionPeriod(sub models.Subscription) (time.Time, *time.Time, error) {
+	start, err := validation.ParseMonthYear(sub.StartDate)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if sub.EndDate == nil {
+		return start, nil, nil
+	}
+	end, err := parseEndDate(*sub.EndDate)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return start, end, nil
+}
+
+// compareSubscriptions сравнивает подписки по полю сортировки; при равенстве — по id.
+func compareSubscriptions(a, b models.Subscription, field string) int {
+	var c int
+	switch field {
+	case "price":
+		c = cmp.Compare(a.Price, b.Price)
+	case "start_date":
+		aStart, _ := validation.ParseMonthYear(a.StartDate)
+		bStart, _ := validation.ParseMonthYear(b.StartDate)
+		c = aStart.Compare(bStart)
+	case "service_name":
+		c = strings.Compare(a.ServiceName, b.ServiceName)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	return c
+}
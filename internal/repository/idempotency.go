@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+)
+
+// reserveAttempts сколько раз Reserve пробует занять ключ, который освобождают параллельно
+const reserveAttempts = 3
+
+var ErrIdempotencyKeyBusy = errors.New("request with this Idempotency-Key is being retried concurrently, try again")
+
+// IdempotencyStore хранилище ключей идемпотентности и сохранённых ответов.
+type IdempotencyStore interface {
+	// Reserve занимает ключ под новый запрос. Если ключ уже занят и не истёк,
+	// возвращает существующую запись и ничего не меняет.
+	Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyRecord, error)
+	// Complete сохраняет ответ для занятого ключа.
+	Complete(ctx context.Context, record models.IdempotencyRecord) error
+	// Release освобождает ключ, чтобы запрос можно было повторить.
+	Release(ctx context.Context, key string) error
+}
+
+var (
+	_ IdempotencyStore = (*IdempotencyRepository)(nil)
+	_ IdempotencyStore = (*MemoryIdempotencyRepository)(nil)
+)
+
+type IdempotencyRepository struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool, logger *zap.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyRecord, error) {
+	// истёкшие ключи удаляем здесь же, отдельная фоновая очистка не нужна
+	if _, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", time.Now()); err != nil {
+		r.logger.Error("failed to delete expired idempotency keys", zap.Error(err))
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, status_code, expires_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (key) DO NOTHING
+	`
+
+	for range reserveAttempts {
+		result, err := r.db.Exec(ctx, query, key, requestHash, expiresAt)
+		if err != nil {
+			r.logger.Error("failed to reserve idempotency key", zap.Error(err))
+			return nil, err
+		}
+		if result.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		var record models.IdempotencyRecord
+		err = r.db.QueryRow(ctx, `
+			SELECT key, request_hash, status_code, headers, body, expires_at
+			FROM idempotency_keys
+			WHERE key = $1
+		`, key).Scan(&record.Key, &record.RequestHash, &record.StatusCode, &record.Headers, &record.Body, &record.ExpiresAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// ключ успели освободить между вставкой и чтением — пробуем занять ещё раз
+			continue
+		}
+		if err != nil {
+			r.logger.Error("failed to get idempotency key", zap.Error(err))
+			return nil, err
+		}
+		return &record, nil
+	}
+	return nil, ErrIdempotencyKeyBusy
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, headers = $2, body = $3
+		WHERE key = $4
+	`
+
+	if _, err := r.db.Exec(ctx, query, record.StatusCode, record.Headers, record.Body, record.Key); err != nil {
+		r.logger.Error("failed to save idempotent response", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := r.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE key = $1", key); err != nil {
+		r.logger.Error("failed to release idempotency key", zap.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"em-internship/internal/models"
+)
+
+// MemoryIdempotencyRepository хранит ключи идемпотентности в памяти процесса.
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{
+		records: make(map[string]models.IdempotencyRecord),
+	}
+}
+
+func (r *MemoryIdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiresAt time.Time) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for k, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, k)
+		}
+	}
+
+	if record, ok := r.records[key]; ok {
+		return &record, nil
+	}
+
+	r.records[key] = models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   expiresAt,
+	}
+	return nil, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.records[record.Key]; ok {
+		record.ExpiresAt = current.ExpiresAt
+		record.RequestHash = current.RequestHash
+	}
+	r.records[record.Key] = record
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	delete(r.records, key)
+	r.mu.Unlock()
+	return nil
+}
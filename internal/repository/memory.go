@@ -0,0 +1,582 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+// MemorySubscriptionRepository хранит подписки в памяти процесса.
+// Используется для локального запуска без PostgreSQL и в тестах; данные теряются при перезапуске.
+type MemorySubscriptionRepository struct {
+	mu          sync.RWMutex
+	subs        map[string]models.Subscription
+	prices      map[string][]models.SubscriptionPrice // изменения цены по id подписки, по возрастанию месяца
+	events      map[string][]models.SubscriptionEvent // журнал изменений по id подписки, сохраняется после удаления
+	lastEventID int64
+	undo        undoLog // прежние значения для отката, только у хранилища внутри WithinTx
+	logger      *zap.Logger
+}
+
+func NewMemorySubscriptionRepository(logger *zap.Logger) *MemorySubscriptionRepository {
+	return &MemorySubscriptionRepository{
+		subs:   make(map[string]models.Subscription),
+		prices: make(map[string][]models.SubscriptionPrice),
+		events: make(map[string][]models.SubscriptionEvent),
+		logger: logger,
+	}
+}
+
+func (r *MemorySubscriptionRepository) Create(ctx context.Context, input models.CreateSubscriptionInput) (*models.Subscription, error) {
+	if _, err := validation.ParseMonthYear(input.StartDate); err != nil {
+		return nil, err
+	}
+	if _, err := parseEndDate(input.EndDate); err != nil {
+		return nil, err
+	}
+
+	nowTime := time.Now()
+	sub := models.Subscription{
+		ID:              uuid.New().String(),
+		ServiceName:     input.ServiceName,
+		ServiceID:       optionalID(input.ServiceID),
+		Price:           input.Price,
+		UserID:          input.UserID,
+		StartDate:       input.StartDate,
+		BillingPeriod:   input.BillingPeriod,
+		BillingInterval: input.BillingInterval,
+		Currency:        input.Currency,
+		Category:        input.Category,
+		Tags:            models.NormalizeTags(input.Tags),
+		Version:         1,
+		CreatedAt:       nowTime,
+		UpdatedAt:       nowTime,
+	}
+	if input.EndDate != "" {
+		endDate := input.EndDate
+		sub.EndDate = &endDate
+	}
+
+	event, err := newSubscriptionEvent(ctx, models.EventCreated, nil, &sub)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.remember(sub.ID)
+	r.subs[sub.ID] = sub
+	r.appendEvent(event)
+	r.mu.Unlock()
+
+	r.logger.Info("created subscription", zap.String("id", sub.ID), zap.String("user_id", input.UserID))
+
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) GetByID(ctx context.Context, id string) (*models.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sub, ok := r.activeSub(id)
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) GetAll(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error) {
+	subs, err := r.selectSorted(params.SubscriptionFilter, params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Cursor != nil {
+		return memoryCursorPage(subs, params)
+	}
+
+	total := len(subs)
+	offset := min(max(params.Offset, 0), total)
+	end := min(offset+params.Limit, total)
+
+	return &models.SubscriptionList{
+		Items: subs[offset:end],
+		Total: &total,
+	}, nil
+}
+
+// Export передаёт fn подписки по фильтру в порядке sortBy. Выборка копируется под блокировкой,
+// чтобы fn могла писать в медленное соединение, не задерживая изменения.
+func (r *MemorySubscriptionRepository) Export(ctx context.Context, filter models.SubscriptionFilter, sortBy string, fn func(*models.Subscription) error) error {
+	subs, err := r.selectSorted(filter, sortBy)
+	if err != nil {
+		return err
+	}
+	for i := range subs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&subs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectSorted подписки по фильтру в порядке sortBy.
+func (r *MemorySubscriptionRepository) selectSorted(filter models.SubscriptionFilter, sortBy string) ([]models.Subscription, error) {
+	field, desc, err := parseSort(sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	subs := make([]models.Subscription, 0, len(r.subs))
+	for _, sub := range r.subs {
+		ok, err := matchesFilter(sub, filter)
+		if err != nil {
+			r.mu.RUnlock()
+			return nil, err
+		}
+		if ok {
+			subs = append(subs, sub)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(subs, func(i, j int) bool {
+		c := compareSubscriptions(subs[i], subs[j], field)
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	return subs, nil
+}
+
+// memoryCursorPage keyset-пагинация по отсортированному списку, аналог getAllByCursor.
+func memoryCursorPage(subs []models.Subscription, params models.ListSubscriptionsParams) (*models.SubscriptionList, error) {
+	desc, err := cursorDirection(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(*params.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if cursor != nil {
+		pos := models.Subscription{ID: cursor.ID, CreatedAt: cursor.CreatedAt}
+		start = sort.Search(len(subs), func(i int) bool {
+			c := compareSubscriptions(subs[i], pos, "created_at")
+			if desc {
+				return c < 0
+			}
+			return c > 0
+		})
+	}
+
+	end := min(start+params.Limit+1, len(subs))
+	return cursorPage(append([]models.Subscription{}, subs[start:end]...), params.Limit), nil
+}
+
+// Update полностью заменяет изменяемые поля подписки и увеличивает версию.
+func (r *MemorySubscriptionRepository) Update(ctx context.Context, id string, input models.ReplaceSubscriptionInput, expectedVersion int) (*models.Subscription, error) {
+	if _, err := validation.ParseMonthYear(input.StartDate); err != nil {
+		return nil, err
+	}
+	if _, err := parseEndDate(input.EndDate); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.activeSub(id)
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	if expectedVersion != 0 && sub.Version != expectedVersion {
+		return nil, ErrVersionMismatch
+	}
+	before := sub
+
+	sub.ServiceName = input.ServiceName
+	sub.ServiceID = optionalID(input.ServiceID)
+	sub.Price = input.Price
+	sub.UserID = input.UserID
+	sub.StartDate = input.StartDate
+	sub.BillingPeriod = input.BillingPeriod
+	sub.BillingInterval = input.BillingInterval
+	sub.Currency = input.Currency
+	sub.Category = input.Category
+	sub.Tags = models.NormalizeTags(input.Tags)
+	sub.EndDate = nil
+	if input.EndDate != "" {
+		endDate := input.EndDate
+		sub.EndDate = &endDate
+	}
+	sub.Version++
+	sub.UpdatedAt = time.Now()
+
+	event, err := newSubscriptionEvent(ctx, models.EventUpdated, &before, &sub)
+	if err != nil {
+		return nil, err
+	}
+	r.remember(id)
+	r.subs[id] = sub
+	r.appendEvent(event)
+
+	r.logger.Info("subscription updated", zap.String("id", id))
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) Delete(ctx context.Context, id string, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.activeSub(id)
+	if !ok {
+		return ErrSubscriptionNotFound
+	}
+	if expectedVersion != 0 && sub.Version != expectedVersion {
+		return ErrVersionMismatch
+	}
+
+	before := sub
+	now := time.Now()
+	sub.DeletedAt = &now
+	sub.UpdatedAt = now
+	sub.Version++
+
+	event, err := newSubscriptionEvent(ctx, models.EventDeleted, &before, &sub)
+	if err != nil {
+		return err
+	}
+	r.remember(id)
+	r.subs[id] = sub
+	r.appendEvent(event)
+
+	r.logger.Info("subscription deleted", zap.String("id", id))
+	return nil
+}
+
+// Restore восстанавливает мягко удалённую подписку.
+func (r *MemorySubscriptionRepository) Restore(ctx context.Context, id string) (*models.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	if sub.DeletedAt == nil {
+		return nil, ErrSubscriptionNotDeleted
+	}
+	before := sub
+	sub.DeletedAt = nil
+	sub.UpdatedAt = time.Now()
+	sub.Version++
+
+	event, err := newSubscriptionEvent(ctx, models.EventRestored, &before, &sub)
+	if err != nil {
+		return nil, err
+	}
+	r.remember(id)
+	r.subs[id] = sub
+	r.appendEvent(event)
+
+	r.logger.Info("subscription restored", zap.String("id", id))
+	return &sub, nil
+}
+
+func (r *MemorySubscriptionRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, sub := range r.subs {
+		if sub.DeletedAt == nil || !sub.DeletedAt.Before(before) {
+			continue
+		}
+		event, err := newSubscriptionEvent(ctx, models.EventPurged, &sub, nil)
+		if err != nil {
+			return purged, err
+		}
+		r.remember(id)
+		delete(r.subs, id)
+		delete(r.prices, id)
+		r.appendEvent(event)
+		purged++
+	}
+
+	r.logger.Info("purged deleted subscriptions", zap.Int("count", purged), zap.Time("before", before))
+	return purged, nil
+}
+
+// GetTotalCostForPeriod считает стоимость по тем же правилам, что и SubscriptionRepository.
+func (r *MemorySubscriptionRepository) GetTotalCostForPeriod(ctx context.Context, params models.CostParams) (*models.TotalCostResponse, error) {
+	calc, err := r.calculateCosts(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return calc.response, nil
+}
+
+func (r *MemorySubscriptionRepository) GetCostBreakdown(ctx context.Context, params models.CostParams) (*models.CostBreakdownResponse, error) {
+	calc, err := r.calculateCosts(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return calc.breakdown(params.GroupBy), nil
+}
+
+func (r *MemorySubscriptionRepository) calculateCosts(params models.CostParams) (*costCalculator, error) {
+	calc, err := newCostCalculator(params)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjects := make([]costSubject, 0, len(r.subs))
+	for _, sub := range r.subs {
+		if !costMatches(sub, params) {
+			continue
+		}
+
+		start, end, err := subscriptionPeriod(sub)
+		if err != nil {
+			return nil, err
+		}
+		subject := costSubject{
+			ID:              sub.ID,
+			ServiceName:     sub.ServiceName,
+			UserID:          sub.UserID,
+			Category:        sub.Category,
+			Price:           sub.Price,
+			Currency:        sub.Currency,
+			BillingPeriod:   sub.BillingPeriod,
+			BillingInterval: sub.BillingInterval,
+			Start:           start,
+			End:             end,
+		}
+		for _, p := range r.prices[sub.ID] {
+			month, err := validation.ParseMonthYear(p.EffectiveFrom)
+			if err != nil {
+				return nil, err
+			}
+			subject.Prices = append(subject.Prices, priceChange{month: monthIndex(month), price: p.Price})
+		}
+		subjects = append(subjects, subject)
+	}
+
+	// тот же порядок, что и в PostgreSQL: по дате начала, затем по id
+	sort.Slice(subjects, func(i, j int) bool {
+		if !subjects[i].Start.Equal(subjects[j].Start) {
+			return subjects[i].Start.Before(subjects[j].Start)
+		}
+		return subjects[i].ID < subjects[j].ID
+	})
+
+	for _, s := range subjects {
+		if err := calc.add(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return calc, nil
+}
+
+// costMatches подходит ли подписка под фильтры расчёта стоимости; период проверяет costCalculator.
+func costMatches(sub models.Subscription, params models.CostParams) bool {
+	if sub.DeletedAt != nil {
+		return false
+	}
+	if params.UserID != "" && sub.UserID != params.UserID {
+		return false
+	}
+	if params.ServiceID != "" && (sub.ServiceID == nil || *sub.ServiceID != params.ServiceID) {
+		return false
+	}
+	return params.ServiceName == "" || sub.ServiceName == params.ServiceName
+}
+
+func (r *MemorySubscriptionRepository) CostCurrencies(ctx context.Context, params models.CostParams) ([]string, error) {
+	periodStart, err := validation.ParseMonthYear(params.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	periodEnd, err := validation.ParseMonthYear(params.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var currencies []string
+	for _, sub := range r.subs {
+		if !costMatches(sub, params) || slices.Contains(currencies, sub.Currency) {
+			continue
+		}
+		start, end, err := subscriptionPeriod(sub)
+		if err != nil {
+			return nil, err
+		}
+		if _, _, ok := billedRange(start, end, periodStart, periodEnd); ok {
+			currencies = append(currencies, sub.Currency)
+		}
+	}
+	return currencies, nil
+}
+
+func (r *MemorySubscriptionRepository) LinkService(ctx context.Context, serviceID, name string, names []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	linked := 0
+	for id, sub := range r.subs {
+		if sub.DeletedAt != nil {
+			continue
+		}
+		linkedHere := sub.ServiceID != nil && *sub.ServiceID == serviceID && sub.ServiceName != name
+		matches := sub.ServiceID == nil && slices.Contains(names, models.NormalizeServiceName(sub.ServiceName))
+		if !linkedHere && !matches {
+			continue
+		}
+		before := sub
+		linkedID := serviceID
+		sub.ServiceID = &linkedID
+		sub.ServiceName = name
+		sub.Version++
+		sub.UpdatedAt = time.Now()
+
+		event, err := newSubscriptionEvent(ctx, models.EventUpdated, &before, &sub)
+		if err != nil {
+			return linked, err
+		}
+		r.remember(id)
+		r.subs[id] = sub
+		r.appendEvent(event)
+		linked++
+	}
+	return linked, nil
+}
+
+func (r *MemorySubscriptionRepository) ListEvents(ctx context.Context, subscriptionID string) ([]models.SubscriptionEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events, ok := r.events[subscriptionID]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return append([]models.SubscriptionEvent{}, events...), nil
+}
+
+// WithinTx выполняет fn над тем же хранилищем, запоминая прежние значения изменённых подписок:
+// при ошибке fn они возвращаются на место. Данные не копируются, поэтому вложенные транзакции
+// (по одной на операцию пакета) стоят столько же, сколько сами изменения.
+// Остальные запросы ждут окончания транзакции.
+func (r *MemorySubscriptionRepository) WithinTx(ctx context.Context, fn func(SubscriptionStore) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &MemorySubscriptionRepository{
+		subs:        r.subs,
+		prices:      r.prices,
+		events:      r.events,
+		lastEventID: r.lastEventID,
+		undo:        undoLog{},
+		logger:      r.logger,
+	}
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	r.lastEventID = tx.lastEventID
+	if r.undo != nil {
+		// откат внешней транзакции должен вернуть и то, что изменила вложенная
+		for id, entry := range tx.undo {
+			if _, ok := r.undo[id]; !ok {
+				r.undo[id] = entry
+			}
+		}
+	}
+	return nil
+}
+
+// undoLog состояние подписок до первого изменения в транзакции, по id подписки.
+type undoLog map[string]undoEntry
+
+type undoEntry struct {
+	sub    *models.Subscription // nil — подписки не было
+	prices []models.SubscriptionPrice
+	events []models.SubscriptionEvent
+}
+
+// remember запоминает подписку, её цены и журнал перед изменением в транзакции; вызывается под r.mu.
+// Срезы копируются: изменения цен меняют их на месте.
+func (r *MemorySubscriptionRepository) remember(id string) {
+	if r.undo == nil {
+		return
+	}
+	if _, ok := r.undo[id]; ok {
+		return
+	}
+	var entry undoEntry
+	if sub, ok := r.subs[id]; ok {
+		entry.sub = &sub
+	}
+	entry.prices = slices.Clone(r.prices[id])
+	entry.events = slices.Clone(r.events[id])
+	r.undo[id] = entry
+}
+
+// rollback возвращает изменённые в транзакции подписки в прежнее состояние.
+func (r *MemorySubscriptionRepository) rollback() {
+	for id, entry := range r.undo {
+		if entry.sub != nil {
+			r.subs[id] = *entry.sub
+		} else {
+			delete(r.subs, id)
+		}
+		if entry.prices != nil {
+			r.prices[id] = entry.prices
+		} else {
+			delete(r.prices, id)
+		}
+		if entry.events != nil {
+			r.events[id] = entry.events
+		} else {
+			delete(r.events, id)
+		}
+	}
+}
+
+// activeSub подписка, если она есть и не удалена; вызывается под r.mu.
+func (r *MemorySubscriptionRepository) activeSub(id string) (models.Subscription, bool) {
+	sub, ok := r.subs[id]
+	return sub, ok && sub.DeletedAt == nil
+}
+
+// appendEvent добавляет событие в журнал; вызывается под r.mu вместе с самим изменением.
+func (r *MemorySubscriptionRepository) appendEvent(event models.SubscriptionEvent) {
+	r.lastEventID++
+	event.ID = r.lastEventID
+	r.events[event.SubscriptionID] = append(r.events[event.SubscriptionID], event)
+}
@@ -0,0 +1,469 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/audit"
+	"em-internship/internal/models"
+)
+
+const testUserID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+func TestMemorySubscriptionRepository_CRUD(t *testing.T) {
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	ctx := context.Background()
+
+	sub, err := repo.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserID:      testUserID,
+		StartDate:   "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceName != "Yandex Plus" || got.EndDate != nil {
+		t.Errorf("GetByID() = %+v", got)
+	}
+
+	updated, err := repo.Update(ctx, sub.ID, models.ReplaceSubscriptionInput{
+		ServiceName: "Yandex Plus",
+		Price:       500,
+		UserID:      testUserID,
+		StartDate:   "07-2025",
+		EndDate:     "12-2025",
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Price != 500 || updated.EndDate == nil || *updated.EndDate != "12-2025" {
+		t.Errorf("Update() = %+v", updated)
+	}
+
+	list, err := repo.GetAll(ctx, models.ListSubscriptionsParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *list.Total != 1 || len(list.Items) != 1 {
+		t.Errorf("GetAll() total = %d, items = %d", *list.Total, len(list.Items))
+	}
+
+	if err := repo.Delete(ctx, sub.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(ctx, sub.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, 0); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestMemorySubscriptionRepository_GetTotalCostForPeriod(t *testing.T) {
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	ctx := context.Background()
+
+	inputs := []models.CreateSubscriptionInput{
+		{ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "12-2024"},
+		{ServiceName: "Netflix", Price: 1000, UserID: testUserID, StartDate: "03-2025", EndDate: "05-2025"},
+		{ServiceName: "Spotify", Price: 300, UserID: testUserID, StartDate: "01-2024", EndDate: "12-2024"},
+		{ServiceName: "Yandex Plus", Price: 400, UserID: "8f8f0a4e-0c2f-4a53-9b8c-6a2f4b0b6f11", StartDate: "01-2025"},
+	}
+	for _, input := range inputs {
+		if _, err := repo.Create(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := repo.GetTotalCostForPeriod(ctx, models.CostParams{UserID: testUserID, StartDate: "01-2025", EndDate: "12-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 400 × 12 + 1000 × 3
+	if got.TotalCost != 7800 || got.Count != 2 {
+		t.Errorf("GetTotalCostForPeriod() = %d/%d, want 7800/2", got.TotalCost, got.Count)
+	}
+}
+
+func TestMemorySubscriptionRepository_GetAllFilters(t *testing.T) {
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	ctx := context.Background()
+
+	inputs := []models.CreateSubscriptionInput{
+		{ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "12-2024", Category: "music", Tags: []string{"family", "Work"}},
+		{ServiceName: "yandex Music", Price: 250, UserID: testUserID, StartDate: "03-2025", EndDate: "05-2025", Category: "music", Tags: []string{"work"}},
+		{ServiceName: "Netflix", Price: 1000, UserID: testUserID, StartDate: "01-2024", Category: "streaming", Tags: []string{"family"}},
+		{ServiceName: "Yandex Plus", Price: 400, UserID: "8f8f0a4e-0c2f-4a53-9b8c-6a2f4b0b6f11", StartDate: "01-2025"},
+	}
+	for _, input := range inputs {
+		if _, err := repo.Create(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	maxPrice := models.Money(500)
+	tests := []struct {
+		name      string
+		params    models.ListSubscriptionsParams
+		wantNames []string
+	}{
+		{
+			name: "prefix case-insensitive sorted by price",
+			params: models.ListSubscriptionsParams{
+				SubscriptionFilter: models.SubscriptionFilter{UserID: testUserID, ServiceNamePrefix: "YANDEX"},
+				Sort:               "price",
+			},
+			wantNames: []string{"yandex Music", "Yandex Plus"},
+		},
+		{
+			name: "active in month with max price",
+			params: models.ListSubscriptionsParams{
+				SubscriptionFilter: models.SubscriptionFilter{ActiveIn: "06-2025", MaxPrice: &maxPrice},
+				Sort:               "-start_date",
+			},
+			wantNames: []string{"Yandex Plus", "Yandex Plus"},
+		},
+		{
+			name: "exact service name sorted by name",
+			params: models.ListSubscriptionsParams{
+				SubscriptionFilter: models.SubscriptionFilter{ServiceName: "Netflix"},
+				Sort:               "service_name",
+			},
+			wantNames: []string{"Netflix"},
+		},
+		{
+			name: "category",
+			params: models.ListSubscriptionsParams{
+				SubscriptionFilter: models.SubscriptionFilter{Category: "music"},
+				Sort:               "price",
+			},
+			wantNames: []string{"yandex Music", "Yandex Plus"},
+		},
+		{
+			name: "all tags required",
+			params: models.ListSubscriptionsParams{
+				SubscriptionFilter: models.SubscriptionFilter{Tags: []string{"family", "work"}},
+			},
+			wantNames: []string{"Yandex Plus"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.params.Limit = 10
+			list, err := repo.GetAll(ctx, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *list.Total != len(tt.wantNames) {
+				t.Fatalf("total = %d, want %d", *list.Total, len(tt.wantNames))
+			}
+			for i, sub := range list.Items {
+				if sub.ServiceName != tt.wantNames[i] {
+					t.Errorf("items[%d] = %q, want %q", i, sub.ServiceName, tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemorySubscriptionRepository_GetAllCursor(t *testing.T) {
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	ctx := context.Background()
+
+	created := make(map[string]bool)
+	for range 5 {
+		sub, err := repo.Create(ctx, models.CreateSubscriptionInput{
+			ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		created[sub.ID] = true
+	}
+
+	cursor := ""
+	seen := make(map[string]bool)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		list, err := repo.GetAll(ctx, models.ListSubscriptionsParams{Limit: 2, Cursor: &cursor})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total != nil {
+			t.Error("total must not be counted in cursor mode")
+		}
+		for _, sub := range list.Items {
+			if seen[sub.ID] {
+				t.Errorf("duplicate subscription %s", sub.ID)
+			}
+			seen[sub.ID] = true
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		cursor = list.NextCursor
+	}
+
+	if len(seen) != len(created) {
+		t.Errorf("visited %d subscriptions, want %d", len(seen), len(created))
+	}
+
+	bad := "not-a-cursor"
+	if _, err := repo.GetAll(ctx, models.ListSubscriptionsParams{Limit: 2, Cursor: &bad}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestMemorySubscriptionRepository_Events(t *testing.T) {
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	ctx := audit.WithInfo(context.Background(), audit.Info{Actor: "admin@example.com", RequestID: "host/abc-000001"})
+
+	sub, err := repo.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: "07-2025", Tags: []string{"family"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Update(ctx, sub.ID, models.ReplaceSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 45000, UserID: testUserID, StartDate: "07-2025", Tags: []string{"family"},
+	}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "01-2026", Price: 50000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, sub.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Purge(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := repo.ListEvents(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTypes := []string{models.EventCreated, models.EventUpdated, models.EventPriceAdded, models.EventDeleted, models.EventPurged}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("events = %d, want %d", len(events), len(wantTypes))
+	}
+	for i, event := range events {
+		if event.Type != wantTypes[i] || event.Actor != "admin@example.com" || event.RequestID != "host/abc-000001" {
+			t.Errorf("events[%d] = %+v", i, event)
+		}
+	}
+
+	if created := events[0].Changes["service_name"]; created.Before != nil || created.After != "Yandex Plus" {
+		t.Errorf("created service_name change = %+v", created)
+	}
+	updated := events[1].Changes
+	if len(updated) != 1 || updated["price"].Before != "400.00" || updated["price"].After != "450.00" || events[1].Version != 2 {
+		t.Errorf("updated changes = %+v, version = %d", updated, events[1].Version)
+	}
+	if price := events[2].Changes["prices.01-2026"]; price.Before != nil || price.After != "500.00" {
+		t.Errorf("price change = %+v", price)
+	}
+	if deleted := events[3].Changes; len(deleted) != 1 || deleted["deleted_at"].Before != nil || deleted["deleted_at"].After == nil {
+		t.Errorf("deleted changes = %+v", deleted)
+	}
+	if purged := events[4].Changes["price"]; purged.Before != "450.00" || purged.After != nil {
+		t.Errorf("purged price change = %+v", purged)
+	}
+
+	if _, err := repo.ListEvents(ctx, "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+}
+
+func TestMemorySubscriptionRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+
+	kept, err := repo.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := repo.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, sub.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetByID(ctx, sub.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound for deleted subscription, got %v", err)
+	}
+	if err := repo.Delete(ctx, sub.ID, 0); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound on second delete, got %v", err)
+	}
+	list, err := repo.GetAll(ctx, models.ListSubscriptionsParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != kept.ID {
+		t.Errorf("active list = %+v, want only %s", list.Items, kept.ID)
+	}
+	trash, err := repo.GetAll(ctx, models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{Deleted: true}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Items) != 1 || trash.Items[0].ID != sub.ID || trash.Items[0].DeletedAt == nil {
+		t.Errorf("deleted list = %+v, want only %s", trash.Items, sub.ID)
+	}
+	total, err := repo.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "01-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.TotalCost != 99900 {
+		t.Errorf("total = %s, want 999.00 without the deleted subscription", total.TotalCost)
+	}
+
+	restored, err := repo.Restore(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != nil || restored.Version != sub.Version+2 {
+		t.Errorf("restored = %+v, want no deleted_at and version %d", restored, sub.Version+2)
+	}
+	if _, err := repo.Restore(ctx, sub.ID); !errors.Is(err, ErrSubscriptionNotDeleted) {
+		t.Errorf("expected ErrSubscriptionNotDeleted, got %v", err)
+	}
+
+	// очистка не трогает подписки, удалённые позже границы
+	if err := repo.Delete(ctx, sub.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Errorf("purge before deletion = %d, %v; want 0", purged, err)
+	}
+	if purged, err := repo.Purge(ctx, time.Now().Add(time.Second)); err != nil || purged != 1 {
+		t.Errorf("purge = %d, %v; want 1", purged, err)
+	}
+	if _, err := repo.Restore(ctx, sub.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound after purge, got %v", err)
+	}
+}
+
+func TestMemoryExchangeRateRepository_ListForPeriod(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryExchangeRateRepository()
+	err := repo.Upsert(ctx, []models.ExchangeRateInput{
+		{Currency: "USD", EffectiveFrom: "01-2024", Rate: "90"},
+		{Currency: "USD", EffectiveFrom: "06-2024", Rate: "91"},
+		{Currency: "USD", EffectiveFrom: "03-2025", Rate: "92"},
+		{Currency: "USD", EffectiveFrom: "09-2025", Rate: "93"},
+		{Currency: "EUR", EffectiveFrom: "01-2025", Rate: "100"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	rates, err := repo.ListForPeriod(ctx, []string{"USD", models.BaseCurrency}, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rate := range rates {
+		got = append(got, rate.Currency+"/"+rate.EffectiveFrom)
+	}
+	want := []string{"USD/06-2024", "USD/03-2025"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rates = %v, want %v", got, want)
+	}
+}
+
+func TestMemorySubscriptionRepository_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySubscriptionRepository(zap.NewNop())
+	sub, err := repo.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errRollback := errors.New("rollback")
+
+	var created *models.Subscription
+	err = repo.WithinTx(ctx, func(tx SubscriptionStore) error {
+		// вложенная транзакция зафиксирована, но откатывается вместе с внешней
+		err := tx.WithinTx(ctx, func(tx SubscriptionStore) error {
+			if _, err := tx.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "07-2025", Price: 109900}); err != nil {
+				return err
+			}
+			created, err = tx.Create(ctx, models.CreateSubscriptionInput{
+				ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: "01-2025",
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		// откат вложенной транзакции не трогает изменения внешней
+		err = tx.WithinTx(ctx, func(tx SubscriptionStore) error {
+			if err := tx.Delete(ctx, created.ID, 0); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("nested WithinTx() = %v, want rollback", err)
+		}
+		if _, err := tx.GetByID(ctx, created.ID); err != nil {
+			t.Errorf("subscription of the committed nested tx: %v", err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithinTx() = %v, want rollback", err)
+	}
+
+	if _, err := repo.GetByID(ctx, created.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("created subscription survived rollback: %v", err)
+	}
+	prices, err := repo.ListPrices(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := repo.ListEvents(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 0 || len(events) != 1 {
+		t.Errorf("after rollback: %d price changes and %d events, want 0 and 1", len(prices), len(events))
+	}
+
+	if err := repo.WithinTx(ctx, func(tx SubscriptionStore) error {
+		_, err := tx.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "07-2025", Price: 109900})
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	events, err = repo.ListEvents(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].ID != events[0].ID+1 {
+		t.Errorf("events after commit = %+v", events)
+	}
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+)
+
+var (
+	ErrServiceNotFound  = errors.New("service not found")
+	ErrServiceNameTaken = errors.New("service name or alias is already used by another service")
+	ErrServiceInUse     = errors.New("service is referenced by subscriptions")
+)
+
+// ServiceStore каталог сервисов. Название и синонимы сервиса уникальны без учёта регистра
+// и лишних пробелов (models.NormalizeServiceName) среди всех сервисов каталога.
+type ServiceStore interface {
+	List(ctx context.Context, filter models.ServiceFilter) ([]models.Service, error)
+	GetByID(ctx context.Context, id string) (*models.Service, error)
+	// FindByName ищет сервис по названию или синониму; name сравнивается после нормализации
+	FindByName(ctx context.Context, name string) (*models.Service, error)
+	Create(ctx context.Context, input models.ServiceInput) (*models.Service, error)
+	Update(ctx context.Context, id string, input models.ServiceInput) (*models.Service, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var (
+	_ ServiceStore = (*ServiceRepository)(nil)
+	_ ServiceStore = (*MemoryServiceRepository)(nil)
+)
+
+const serviceColumns = `s.id, s.name, s.aliases, s.category, s.default_price, s.currency, s.website, s.created_at, s.updated_at`
+
+type ServiceRepository struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewServiceRepository(db *pgxpool.Pool, logger *zap.Logger) *ServiceRepository {
+	return &ServiceRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func scanService(row pgx.Row) (*models.Service, error) {
+	var svc models.Service
+	var defaultPrice *int64
+	err := row.Scan(
+		&svc.ID, &svc.Name, &svc.Aliases, &svc.Category, &defaultPrice,
+		&svc.Currency, &svc.Website, &svc.CreatedAt, &svc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if defaultPrice != nil {
+		price := models.Money(*defaultPrice)
+		svc.DefaultPrice = &price
+	}
+	return &svc, nil
+}
+
+// optionalMoney необязательная сумма в виде значения для BIGINT.
+func optionalMoney(m *models.Money) *int64 {
+	if m == nil {
+		return nil
+	}
+	v := int64(*m)
+	return &v
+}
+
+func (r *ServiceRepository) List(ctx context.Context, filter models.ServiceFilter) ([]models.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services s WHERE 1=1`
+	var args []interface{}
+	if filter.Category != "" {
+		args = append(args, filter.Category)
+		query += fmt.Sprintf(" AND s.category = $%d", len(args))
+	}
+	if q := models.NormalizeServiceName(filter.Query); q != "" {
+		args = append(args, escapeLike(q)+"%")
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM service_aliases a WHERE a.service_id = s.id AND a.alias LIKE $%d)", len(args))
+	}
+	query += " ORDER BY lower(s.name), s.id"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to get services", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := []models.Service{}
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, *svc)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get services", zap.Error(err))
+		return nil, err
+	}
+
+	return services, nil
+}
+
+func (r *ServiceRepository) GetByID(ctx context.Context, id string) (*models.Service, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrServiceNotFound
+	}
+
+	svc, err := scanService(r.db.QueryRow(ctx, `SELECT `+serviceColumns+` FROM services s WHERE s.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get service", zap.Error(err), zap.String("id", id))
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (r *ServiceRepository) FindByName(ctx context.Context, name string) (*models.Service, error) {
+	query := `
+		SELECT ` + serviceColumns + `
+		FROM services s
+		JOIN service_aliases a ON a.service_id = s.id
+		WHERE a.alias = $1
+	`
+
+	svc, err := scanService(r.db.QueryRow(ctx, query, models.NormalizeServiceName(name)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to find service by name", zap.Error(err), zap.String("name", name))
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (r *ServiceRepository) Create(ctx context.Context, input models.ServiceInput) (*models.Service, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO services AS s (id, name, aliases, category, default_price, currency, website, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING ` + serviceColumns
+
+	now := time.Now()
+	svc, err := scanService(tx.QueryRow(ctx, query,
+		uuid.New().String(), input.Name, aliasesOrEmpty(input.Aliases), input.Category,
+		optionalMoney(input.DefaultPrice), input.Currency, input.Website, now,
+	))
+	if err != nil {
+		r.logger.Error("failed to create service", zap.Error(err), zap.String("name", input.Name))
+		return nil, fmt.Errorf("failed to create service: %w", err)
+	}
+
+	if err := saveServiceNames(ctx, tx, svc.ID, input); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("created service", zap.String("id", svc.ID), zap.String("name", svc.Name))
+	return svc, nil
+}
+
+func (r *ServiceRepository) Update(ctx context.Context, id string, input models.ServiceInput) (*models.Service, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrServiceNotFound
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE services s
+		SET name = $1, aliases = $2, category = $3, default_price = $4, currency = $5, website = $6, updated_at = $7
+		WHERE s.id = $8
+		RETURNING ` + serviceColumns
+
+	svc, err := scanService(tx.QueryRow(ctx, query,
+		input.Name, aliasesOrEmpty(input.Aliases), input.Category,
+		optionalMoney(input.DefaultPrice), input.Currency, input.Website, time.Now(), id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to update service", zap.Error(err), zap.String("id", id))
+		return nil, fmt.Errorf("failed to update service: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM service_aliases WHERE service_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := saveServiceNames(ctx, tx, id, input); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("updated service", zap.String("id", id))
+	return svc, nil
+}
+
+func (r *ServiceRepository) Delete(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrServiceNotFound
+	}
+
+	result, err := r.db.Exec(ctx, "DELETE FROM services WHERE id = $1", id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+		return ErrServiceInUse
+	}
+	if err != nil {
+		r.logger.Error("failed to delete service", zap.Error(err), zap.String("id", id))
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrServiceNotFound
+	}
+
+	r.logger.Info("service deleted", zap.String("id", id))
+	return nil
+}
+
+// saveServiceNames сохраняет нормализованные название и синонимы сервиса;
+// занятое другим сервисом имя нарушает первичный ключ service_aliases.
+func saveServiceNames(ctx context.Context, tx pgx.Tx, serviceID string, input models.ServiceInput) error {
+	batch := &pgx.Batch{}
+	for _, name := range models.ServiceNames(input.Name, input.Aliases) {
+		batch.Queue("INSERT INTO service_aliases (alias, service_id) VALUES ($1, $2)", name, serviceID)
+	}
+
+	err := tx.SendBatch(ctx, batch).Close()
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		return ErrServiceNameTaken
+	}
+	return err
+}
+
+func aliasesOrEmpty(aliases []string) []string {
+	if aliases == nil {
+		return []string{}
+	}
+	return aliases
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"em-internship/internal/models"
+)
+
+// MemoryServiceRepository хранит каталог сервисов в памяти процесса.
+type MemoryServiceRepository struct {
+	mu       sync.RWMutex
+	services map[string]models.Service
+	names    map[string]string // нормализованное название или синоним -> id сервиса
+}
+
+func NewMemoryServiceRepository() *MemoryServiceRepository {
+	return &MemoryServiceRepository{
+		services: make(map[string]models.Service),
+		names:    make(map[string]string),
+	}
+}
+
+func (r *MemoryServiceRepository) List(ctx context.Context, filter models.ServiceFilter) ([]models.Service, error) {
+	q := models.NormalizeServiceName(filter.Query)
+
+	r.mu.RLock()
+	services := []models.Service{}
+	for _, svc := range r.services {
+		if filter.Category != "" && svc.Category != filter.Category {
+			continue
+		}
+		if q != "" && !hasNamePrefix(svc, q) {
+			continue
+		}
+		services = append(services, svc)
+	}
+	r.mu.RUnlock()
+
+	// тот же порядок, что и в PostgreSQL
+	sort.Slice(services, func(i, j int) bool {
+		a, b := strings.ToLower(services[i].Name), strings.ToLower(services[j].Name)
+		if a != b {
+			return a < b
+		}
+		return services[i].ID < services[j].ID
+	})
+	return services, nil
+}
+
+func hasNamePrefix(svc models.Service, prefix string) bool {
+	for _, name := range models.ServiceNames(svc.Name, svc.Aliases) {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryServiceRepository) GetByID(ctx context.Context, id string) (*models.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	svc, ok := r.services[id]
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+	return &svc, nil
+}
+
+func (r *MemoryServiceRepository) FindByName(ctx context.Context, name string) (*models.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.names[models.NormalizeServiceName(name)]
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+	svc := r.services[id]
+	return &svc, nil
+}
+
+func (r *MemoryServiceRepository) Create(ctx context.Context, input models.ServiceInput) (*models.Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := models.ServiceNames(input.Name, input.Aliases)
+	if r.namesTaken(names, "") {
+		return nil, ErrServiceNameTaken
+	}
+
+	now := time.Now()
+	svc := serviceFromInput(uuid.New().String(), input)
+	svc.CreatedAt = now
+	svc.UpdatedAt = now
+
+	r.services[svc.ID] = svc
+	for _, name := range names {
+		r.names[name] = svc.ID
+	}
+	return &svc, nil
+}
+
+func (r *MemoryServiceRepository) Update(ctx context.Context, id string, input models.ServiceInput) (*models.Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.services[id]
+	if !ok {
+		return nil, ErrServiceNotFound
+	}
+	names := models.ServiceNames(input.Name, input.Aliases)
+	if r.namesTaken(names, id) {
+		return nil, ErrServiceNameTaken
+	}
+
+	svc := serviceFromInput(id, input)
+	svc.CreatedAt = current.CreatedAt
+	svc.UpdatedAt = time.Now()
+
+	r.dropNames(id)
+	r.services[id] = svc
+	for _, name := range names {
+		r.names[name] = id
+	}
+	return &svc, nil
+}
+
+// Delete не проверяет ссылки подписок: в памяти это делает сервисный слой.
+func (r *MemoryServiceRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.services[id]; !ok {
+		return ErrServiceNotFound
+	}
+	r.dropNames(id)
+	delete(r.services, id)
+	return nil
+}
+
+// namesTaken занято ли одно из имён сервисом, отличным от id.
+func (r *MemoryServiceRepository) namesTaken(names []string, id string) bool {
+	for _, name := range names {
+		if owner, ok := r.names[name]; ok && owner != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryServiceRepository) dropNames(id string) {
+	for name, owner := range r.names {
+		if owner == id {
+			delete(r.names, name)
+		}
+	}
+}
+
+func serviceFromInput(id string, input models.ServiceInput) models.Service {
+	svc := models.Service{
+		ID:       id,
+		Name:     input.Name,
+		Aliases:  append([]string{}, input.Aliases...),
+		Category: input.Category,
+		Currency: input.Currency,
+		Website:  input.Website,
+	}
+	if input.DefaultPrice != nil {
+		price := *input.DefaultPrice
+		svc.DefaultPrice = &price
+	}
+	return svc
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"em-internship/internal/models"
+)
+
+// SubscriptionStore хранилище подписок. Реализации: SubscriptionRepository (PostgreSQL)
+// и MemorySubscriptionRepository (в памяти процесса).
+type SubscriptionStore interface {
+	Create(ctx context.Context, input models.CreateSubscriptionInput) (*models.Subscription, error)
+	GetByID(ctx context.Context, id string) (*models.Subscription, error)
+	GetAll(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error)
+	// Export передаёт fn все подписки по фильтру по одной; ошибка fn прерывает выгрузку
+	Export(ctx context.Context, filter models.SubscriptionFilter, sortBy string, fn func(*models.Subscription) error) error
+	// expectedVersion != 0 включает оптимистичную блокировку: при другой версии возвращается ErrVersionMismatch
+	Update(ctx context.Context, id string, input models.ReplaceSubscriptionInput, expectedVersion int) (*models.Subscription, error)
+	// Delete мягкое удаление: подписка скрывается из списков и расчётов до Restore или Purge
+	Delete(ctx context.Context, id string, expectedVersion int) error
+	// Restore возвращает удалённую подписку; для неудалённой — ErrSubscriptionNotDeleted
+	Restore(ctx context.Context, id string) (*models.Subscription, error)
+	// Purge окончательно удаляет подписки, удалённые раньше before; возвращает их число
+	Purge(ctx context.Context, before time.Time) (int, error)
+	// LinkService привязывает к сервису каталога подписки без service_id с тем же нормализованным названием
+	// и обновляет название уже привязанных; возвращает число изменённых подписок
+	LinkService(ctx context.Context, serviceID, name string, names []string) (int, error)
+	GetTotalCostForPeriod(ctx context.Context, params models.CostParams) (*models.TotalCostResponse, error)
+	GetCostBreakdown(ctx context.Context, params models.CostParams) (*models.CostBreakdownResponse, error)
+	// CostCurrencies валюты подписок, попадающих в расчёт с params: для них нужны курсы
+	CostCurrencies(ctx context.Context, params models.CostParams) ([]string, error)
+
+	// история цен: изменения цены подписки с указанного месяца, удаляются вместе с подпиской при Purge
+	ListPrices(ctx context.Context, subscriptionID string) ([]models.SubscriptionPrice, error)
+	AddPrice(ctx context.Context, subscriptionID string, input models.SubscriptionPriceInput) (*models.SubscriptionPrice, error)
+	DeletePrice(ctx context.Context, subscriptionID, effectiveFrom string) error
+
+	// WithinTx выполняет fn в транзакции над store, переданным в fn: при ошибке fn изменения откатываются.
+	// Вложенные вызовы откатывают только свои изменения.
+	WithinTx(ctx context.Context, fn func(store SubscriptionStore) error) error
+
+	// журнал изменений: каждое изменение подписки и её цен записывается вместе с самим изменением
+	ListEvents(ctx context.Context, subscriptionID string) ([]models.SubscriptionEvent, error)
+}
+
+var (
+	_ SubscriptionStore = (*SubscriptionRepository)(nil)
+	_ SubscriptionStore = (*MemorySubscriptionRepository)(nil)
+)
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"go.uber.org/zap"
@@ -10,15 +9,36 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"em-internship/internal/models"
+	"em-internship/internal/validation"
 )
 
-var ErrSubscriptionNotFound = errors.New("subscription not found")
+var (
+	ErrSubscriptionNotFound   = errors.New("subscription not found")
+	ErrSubscriptionNotDeleted = errors.New("subscription is not deleted")
+	ErrVersionMismatch        = errors.New("subscription version mismatch")
+)
+
+// subscriptionColumns порядок колонок должен совпадать со scanSubscription;
+// теги читаются подзапросом, поэтому в RETURNING они ещё прежние
+const subscriptionColumns = `id, service_name, service_id, price, user_id, start_date, end_date, billing_period, billing_interval, currency, category,
+	ARRAY(SELECT tag FROM subscription_tags t WHERE t.subscription_id = subscriptions.id ORDER BY tag),
+	version, created_at, updated_at, deleted_at`
+
+// dbtx общие методы пула и транзакции: Begin внутри транзакции открывает точку сохранения,
+// поэтому методы репозитория работают и внутри WithinTx.
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
 
 type SubscriptionRepository struct {
-	db     *pgxpool.Pool
+	db     dbtx
 	logger *zap.Logger
 }
 
@@ -29,26 +49,116 @@ func NewSubscriptionRepository(db *pgxpool.Pool, logger *zap.Logger) *Subscripti
 	}
 }
 
+// WithinTx выполняет fn в одной транзакции: ошибка fn откатывает все её изменения.
+// Вложенный вызов открывает точку сохранения.
+func (r *SubscriptionRepository) WithinTx(ctx context.Context, fn func(SubscriptionStore) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&SubscriptionRepository{db: tx, logger: r.logger}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// scanSubscription читает строку subscriptionColumns; даты в БД хранятся как DATE, в API — MM-YYYY.
+func scanSubscription(row pgx.Row) (*models.Subscription, error) {
+	var sub models.Subscription
+	var startDate time.Time
+	var endDate *time.Time
+
+	err := row.Scan(
+		&sub.ID, &sub.ServiceName, &sub.ServiceID, &sub.Price, &sub.UserID,
+		&startDate, &endDate, &sub.BillingPeriod, &sub.BillingInterval, &sub.Currency, &sub.Category,
+		&sub.Tags, &sub.Version, &sub.CreatedAt, &sub.UpdatedAt, &sub.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.StartDate = validation.FormatMonthYear(startDate)
+	if endDate != nil {
+		end := validation.FormatMonthYear(*endDate)
+		sub.EndDate = &end
+	}
+
+	return &sub, nil
+}
+
+// saveTags сохраняет теги подписки и возвращает их в том порядке, в котором их читает subscriptionColumns.
+func saveTags(ctx context.Context, tx pgx.Tx, subscriptionID string, tags []string) ([]string, error) {
+	tags = models.NormalizeTags(tags)
+
+	batch := &pgx.Batch{}
+	for _, tag := range tags {
+		batch.Queue("INSERT INTO subscription_tags (subscription_id, tag) VALUES ($1, $2)", subscriptionID, tag)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// optionalID пустой id сохраняется как NULL.
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+// parseEndDate переводит необязательную дату окончания MM-YYYY в DATE; пустая строка — бессрочная подписка.
+func parseEndDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := validation.ParseMonthYear(s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 func (r *SubscriptionRepository) Create(ctx context.Context, input models.CreateSubscriptionInput) (*models.Subscription, error) {
 	id := uuid.New().String()
 	nowTime := time.Now()
 
-	query := `
-		INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-	`
+	startDate, err := validation.ParseMonthYear(input.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := parseEndDate(input.EndDate)
+	if err != nil {
+		return nil, err
+	}
 
-	var sub models.Subscription
-	var endDate sql.NullString
-	if input.EndDate != "" {
-		endDate = sql.NullString{String: input.EndDate, Valid: true}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	err := r.db.QueryRow(ctx, query,
-		id, input.ServiceName, input.Price, input.UserID, input.StartDate, endDate, nowTime, nowTime,
-	).Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-		&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt)
+	query := `
+		INSERT INTO subscriptions (id, service_name, service_id, price, user_id, start_date, end_date, billing_period, billing_interval, currency, category, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, 1, $12, $13)
+		RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(tx.QueryRow(ctx, query,
+		id, input.ServiceName, optionalID(input.ServiceID), int64(input.Price), input.UserID, startDate, endDate,
+		input.BillingPeriod, input.BillingInterval, input.Currency, input.Category, nowTime, nowTime,
+	))
+	if err == nil {
+		sub.Tags, err = saveTags(ctx, tx, id, input.Tags)
+	}
+	if err == nil {
+		err = r.recordEvent(ctx, tx, models.EventCreated, nil, sub)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
 
 	if err != nil {
 		r.logger.Error("failed to create subscription", zap.Error(err), zap.String("user_id", input.UserID))
@@ -57,23 +167,22 @@ func (r *SubscriptionRepository) Create(ctx context.Context, input models.Create
 
 	r.logger.Info("created subscription", zap.String("id", sub.ID), zap.String("user_id", input.UserID))
 
-	return &sub, nil
+	return sub, nil
 }
 
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*models.Subscription, error) {
+	// id не UUID не может существовать; иначе PostgreSQL вернёт ошибку приведения типа
+	if uuid.Validate(id) != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var sub models.Subscription
-	var endDate sql.NullString
-
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-		&sub.StartDate, &endDate, &sub.CreatedAt, &sub.UpdatedAt,
-	)
+	sub, err := scanSubscription(r.db.QueryRow(ctx, query, id))
 
 	if err == pgx.ErrNoRows {
 		return nil, ErrSubscriptionNotFound
@@ -84,24 +193,31 @@ func (r *SubscriptionRepository) GetByID(ctx context.Context, id string) (*model
 		return nil, err
 	}
 
-	if endDate.Valid {
-		sub.EndDate = &endDate.String
+	return sub, nil
+}
+
+func (r *SubscriptionRepository) GetAll(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error) {
+	if params.Cursor != nil {
+		return r.getAllByCursor(ctx, params)
 	}
 
-	return &sub, nil
-}
+	where, args, err := buildSubscriptionWhere(params.SubscriptionFilter)
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := buildSubscriptionOrder(params.Sort)
+	if err != nil {
+		return nil, err
+	}
 
-func (r *SubscriptionRepository) GetAll(ctx context.Context, limit, offset int) (*models.SubscriptionList, error) {
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-		FROM subscriptions
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions` + where + orderBy +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
 
-	countQuery := "SELECT COUNT(*) FROM subscriptions"
+	countQuery := "SELECT COUNT(*) FROM subscriptions" + where
 
-	rows, err := r.db.Query(ctx, query, limit, offset)
+	rows, err := r.db.Query(ctx, query, append(args, params.Limit, params.Offset)...)
 	if err != nil {
 		r.logger.Error("failed to get subscriptions", zap.Error(err))
 		return nil, err
@@ -110,92 +226,177 @@ func (r *SubscriptionRepository) GetAll(ctx context.Context, limit, offset int)
 
 	var subs []models.Subscription
 	for rows.Next() {
-		var sub models.Subscription
-		var endDate sql.NullString
-
-		err := rows.Scan(
-			&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-			&sub.StartDate, &endDate, &sub.CreatedAt, &sub.UpdatedAt,
-		)
+		sub, err := scanSubscription(rows)
 		if err != nil {
 			return nil, err
 		}
 
-		if endDate.Valid {
-			sub.EndDate = &endDate.String
-		}
-
-		subs = append(subs, sub)
+		subs = append(subs, *sub)
 	}
 
 	var total int
-	err = r.db.QueryRow(ctx, countQuery).Scan(&total)
+	err = r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		r.logger.Error("failed to count subscriptions", zap.Error(err))
 	}
 
 	return &models.SubscriptionList{
 		Items: subs,
-		Total: total,
+		Total: &total,
 	}, nil
 }
 
-func (r *SubscriptionRepository) Update(ctx context.Context, id string, input models.UpdateSubscriptionInput) (*models.Subscription, error) {
-	query := `
-		UPDATE subscriptions 
-		SET service_name = COALESCE($1, service_name),
-			price = COALESCE($2, price),
-			user_id = COALESCE($3, user_id),
-			start_date = COALESCE($4, start_date),
-			end_date = COALESCE($5, end_date),
-			updated_at = $6
-		WHERE id = $7
-		RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-	`
+// Export передаёт fn подписки по фильтру в порядке sortBy. pgx читает строки из соединения
+// по мере вызова rows.Next, поэтому выборка не загружается в память целиком.
+func (r *SubscriptionRepository) Export(ctx context.Context, filter models.SubscriptionFilter, sortBy string, fn func(*models.Subscription) error) error {
+	where, args, err := buildSubscriptionWhere(filter)
+	if err != nil {
+		return err
+	}
+	orderBy, err := buildSubscriptionOrder(sortBy)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.Query(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions`+where+orderBy, args...)
+	if err != nil {
+		r.logger.Error("failed to export subscriptions", zap.Error(err))
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 
-	sub, err := r.GetByID(ctx, id)
+// getAllByCursor keyset-пагинация по (created_at, id): страница не сдвигается при вставках и удалениях
+// между запросами, а COUNT(*) не выполняется.
+func (r *SubscriptionRepository) getAllByCursor(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error) {
+	desc, err := cursorDirection(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := decodeCursor(*params.Cursor)
 	if err != nil {
 		return nil, err
 	}
 
-	serviceName := sub.ServiceName
-	if input.ServiceName != nil {
-		serviceName = *input.ServiceName
+	where, args, err := buildSubscriptionWhere(params.SubscriptionFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		where += fmt.Sprintf(" AND (created_at, id) %s ($%d, $%d)", op, len(args)-1, len(args))
+	}
+
+	orderBy, err := buildSubscriptionOrder(params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	// запрашиваем на одну строку больше, чтобы понять, есть ли следующая страница
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions` + where + orderBy +
+		fmt.Sprintf(" LIMIT $%d", len(args)+1)
+
+	rows, err := r.db.Query(ctx, query, append(args, params.Limit+1)...)
+	if err != nil {
+		r.logger.Error("failed to get subscriptions", zap.Error(err))
+		return nil, err
 	}
+	defer rows.Close()
+
+	subs := []models.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
 
-	price := sub.Price
-	if input.Price != nil {
-		price = *input.Price
+		subs = append(subs, *sub)
 	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get subscriptions", zap.Error(err))
+		return nil, err
+	}
+
+	return cursorPage(subs, params.Limit), nil
+}
 
-	userID := sub.UserID
-	if input.UserID != nil {
-		userID = *input.UserID
+// Update полностью заменяет изменяемые поля подписки и увеличивает версию.
+// Если expectedVersion != 0, изменение применяется только к подписке этой версии.
+func (r *SubscriptionRepository) Update(ctx context.Context, id string, input models.ReplaceSubscriptionInput, expectedVersion int) (*models.Subscription, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrSubscriptionNotFound
 	}
 
-	startDate := sub.StartDate
-	if input.StartDate != nil {
-		startDate = *input.StartDate
+	startDate, err := validation.ParseMonthYear(input.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := parseEndDate(input.EndDate)
+	if err != nil {
+		return nil, err
 	}
 
-	var endDate *string
-	if input.EndDate != nil {
-		endDate = input.EndDate
-	} else {
-		endDate = sub.EndDate
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	var endDateSQL sql.NullString
-	if endDate != nil {
-		endDateSQL = sql.NullString{String: *endDate, Valid: true}
+	before, err := lockForChange(ctx, tx, id, expectedVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	err = r.db.QueryRow(ctx, query,
-		serviceName, price, userID, startDate, endDateSQL, time.Now(), id,
-	).Scan(
-		&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-		&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt,
-	)
+	query := `
+		UPDATE subscriptions
+		SET service_name = $1,
+			service_id = $2,
+			price = $3,
+			user_id = $4,
+			start_date = $5,
+			end_date = $6,
+			billing_period = $7,
+			billing_interval = $8,
+			currency = $9,
+			category = $10,
+			updated_at = $11,
+			version = version + 1
+		WHERE id = $12
+		RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(tx.QueryRow(ctx, query,
+		input.ServiceName, optionalID(input.ServiceID), int64(input.Price), input.UserID, startDate, endDate,
+		input.BillingPeriod, input.BillingInterval, input.Currency, input.Category, time.Now(), id,
+	))
+	if err == nil {
+		if _, err = tx.Exec(ctx, "DELETE FROM subscription_tags WHERE subscription_id = $1", id); err == nil {
+			sub.Tags, err = saveTags(ctx, tx, id, input.Tags)
+		}
+	}
+	if err == nil {
+		err = r.recordEvent(ctx, tx, models.EventUpdated, before, sub)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
 
 	if err != nil {
 		r.logger.Error("failed to update subscription", zap.Error(err), zap.String("id", id))
@@ -206,64 +407,353 @@ func (r *SubscriptionRepository) Update(ctx context.Context, id string, input mo
 	return sub, nil
 }
 
-func (r *SubscriptionRepository) Delete(ctx context.Context, id string) error {
-	query := "DELETE FROM subscriptions WHERE id = $1"
+// Delete мягко удаляет подписку: она пропадает из списков и расчётов, но её можно восстановить до очистки.
+// Если expectedVersion != 0 — удаляется только подписка этой версии.
+func (r *SubscriptionRepository) Delete(ctx context.Context, id string, expectedVersion int) error {
+	if uuid.Validate(id) != nil {
+		return ErrSubscriptionNotFound
+	}
 
-	result, err := r.db.Exec(ctx, query, id)
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		r.logger.Error("failed to delete subscription", zap.Error(err), zap.String("id", id))
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if result.RowsAffected() == 0 {
-		return ErrSubscriptionNotFound
+	before, err := lockForChange(ctx, tx, id, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	query := `
+		UPDATE subscriptions SET deleted_at = $1, updated_at = $1, version = version + 1
+		WHERE id = $2
+		RETURNING ` + subscriptionColumns
+
+	after, err := scanSubscription(tx.QueryRow(ctx, query, now, id))
+	if err == nil {
+		err = r.recordEvent(ctx, tx, models.EventDeleted, before, after)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		r.logger.Error("failed to delete subscription", zap.Error(err), zap.String("id", id))
+		return err
 	}
 
 	r.logger.Info("subscription deleted", zap.String("id", id))
 	return nil
 }
 
-// GetTotalCostForPeriod считает сумму цен подписок, активных хотя бы один день в периоде [startDate, endDate].
+// Restore восстанавливает мягко удалённую подписку.
+func (r *SubscriptionRepository) Restore(ctx context.Context, id string) (*models.Subscription, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := scanSubscription(tx.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if before.DeletedAt == nil {
+		return nil, ErrSubscriptionNotDeleted
+	}
+
+	query := `
+		UPDATE subscriptions SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2
+		RETURNING ` + subscriptionColumns
+
+	sub, err := scanSubscription(tx.QueryRow(ctx, query, time.Now(), id))
+	if err == nil {
+		err = r.recordEvent(ctx, tx, models.EventRestored, before, sub)
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		r.logger.Error("failed to restore subscription", zap.Error(err), zap.String("id", id))
+		return nil, err
+	}
+
+	r.logger.Info("subscription restored", zap.String("id", id))
+	return sub, nil
+}
+
+// Purge окончательно удаляет подписки, мягко удалённые раньше before, вместе с ценами и тегами.
+// Журнал изменений сохраняется и получает событие purged.
+func (r *SubscriptionRepository) Purge(ctx context.Context, before time.Time) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `DELETE FROM subscriptions WHERE deleted_at < $1 RETURNING `+subscriptionColumns, before)
+	if err != nil {
+		r.logger.Error("failed to purge subscriptions", zap.Error(err))
+		return 0, err
+	}
+	var purged []*models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		purged = append(purged, sub)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to purge subscriptions", zap.Error(err))
+		return 0, err
+	}
+
+	for _, sub := range purged {
+		if err := r.recordEvent(ctx, tx, models.EventPurged, sub, nil); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("purged deleted subscriptions", zap.Int("count", len(purged)), zap.Time("before", before))
+	return len(purged), nil
+}
+
+// lockForChange читает подписку перед изменением и блокирует её до конца транзакции.
+// Возвращает ErrSubscriptionNotFound, если подписки нет, и ErrVersionMismatch, если версия уже другая.
+func lockForChange(ctx context.Context, tx pgx.Tx, id string, expectedVersion int) (*models.Subscription, error) {
+	sub, err := scanSubscription(tx.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expectedVersion != 0 && sub.Version != expectedVersion {
+		return nil, ErrVersionMismatch
+	}
+	return sub, nil
+}
+
+// recordEvent пишет событие журнала в транзакции изменения.
+func (r *SubscriptionRepository) recordEvent(ctx context.Context, tx pgx.Tx, eventType string, before, after *models.Subscription) error {
+	event, err := newSubscriptionEvent(ctx, eventType, before, after)
+	if err != nil {
+		return err
+	}
+	return insertEvent(ctx, tx, event)
+}
+
+// GetTotalCostForPeriod считает реальную стоимость подписок за период [StartDate, EndDate]:
+// цена каждой подписки умножается на число её списаний внутри периода (или амортизируется по месяцам).
+func (r *SubscriptionRepository) GetTotalCostForPeriod(ctx context.Context, params models.CostParams) (*models.TotalCostResponse, error) {
+	calc, err := r.calculateCosts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("calculated total cost",
+		zap.String("user_id", params.UserID),
+		zap.String("service_name", params.ServiceName),
+		zap.Stringer("total_cost", calc.response.TotalCost),
+	)
+
+	return calc.response, nil
+}
+
+// GetCostBreakdown разбивает стоимость за период по месяцам, сервисам или пользователям
+// по тем же правилам, что и GetTotalCostForPeriod.
+func (r *SubscriptionRepository) GetCostBreakdown(ctx context.Context, params models.CostParams) (*models.CostBreakdownResponse, error) {
+	calc, err := r.calculateCosts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return calc.breakdown(params.GroupBy), nil
+}
+
+// calculateCosts выбирает подписки, пересекающиеся с периодом, и передаёт их в costCalculator.
 // Подписка активна в периоде, если: start_date <= period_end AND (end_date IS NULL OR end_date >= period_start).
-func (r *SubscriptionRepository) GetTotalCostForPeriod(ctx context.Context, userID, serviceName, startDate, endDate string) (*models.TotalCostResponse, error) {
-	// Собираем запрос без NULL-параметров, чтобы избежать проблем с драйвером
+func (r *SubscriptionRepository) calculateCosts(ctx context.Context, params models.CostParams) (*costCalculator, error) {
+	calc, err := newCostCalculator(params)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := costConditions(params, calc.periodStart, calc.periodEnd)
 	base := `
-		SELECT COALESCE(SUM(price), 0), COUNT(*)
-		FROM subscriptions
-		WHERE start_date <= $1 AND (end_date IS NULL OR end_date >= $2)
-	`
-	args := []interface{}{endDate, startDate}
+		SELECT id, service_name, user_id, category, price, currency, billing_period, billing_interval, start_date, end_date,
+			ARRAY(SELECT effective_from FROM subscription_prices p WHERE p.subscription_id = s.id ORDER BY effective_from),
+			ARRAY(SELECT price FROM subscription_prices p WHERE p.subscription_id = s.id ORDER BY effective_from)
+		FROM subscriptions s
+		WHERE ` + where + " ORDER BY start_date, id"
+
+	rows, err := r.db.Query(ctx, base, args...)
+	if err != nil {
+		r.logger.Error("failed to calculate total cost", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s costSubject
+		var priceMonths []time.Time
+		var prices []int64
+		if err := rows.Scan(&s.ID, &s.ServiceName, &s.UserID, &s.Category, &s.Price, &s.Currency, &s.BillingPeriod, &s.BillingInterval, &s.Start, &s.End, &priceMonths, &prices); err != nil {
+			return nil, err
+		}
+		for i, month := range priceMonths {
+			s.Prices = append(s.Prices, priceChange{month: monthIndex(month), price: models.Money(prices[i])})
+		}
+
+		if err := calc.add(s); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to calculate total cost", zap.Error(err))
+		return nil, err
+	}
+
+	return calc, nil
+}
+
+// costConditions условие WHERE для подписок, пересекающихся с периодом, с фильтрами params.
+// Запрос собирается без NULL-параметров, чтобы избежать проблем с драйвером.
+func costConditions(params models.CostParams, periodStart, periodEnd time.Time) (string, []interface{}) {
+	where := "start_date <= $1 AND (end_date IS NULL OR end_date >= $2) AND deleted_at IS NULL"
+	args := []interface{}{periodEnd, periodStart}
 	pos := 3
 
-	if userID != "" {
-		base += fmt.Sprintf(" AND user_id = $%d", pos)
-		args = append(args, userID)
+	if params.UserID != "" {
+		where += fmt.Sprintf(" AND user_id = $%d", pos)
+		args = append(args, params.UserID)
 		pos++
 	}
-	if serviceName != "" {
-		base += fmt.Sprintf(" AND service_name = $%d", pos)
-		args = append(args, serviceName)
+	if params.ServiceID != "" {
+		where += fmt.Sprintf(" AND service_id = $%d", pos)
+		args = append(args, params.ServiceID)
 		pos++
 	}
+	if params.ServiceName != "" {
+		where += fmt.Sprintf(" AND service_name = $%d", pos)
+		args = append(args, params.ServiceName)
+	}
+	return where, args
+}
 
-	var totalCost int64
-	var count int
-
-	err := r.db.QueryRow(ctx, base, args...).Scan(&totalCost, &count)
+func (r *SubscriptionRepository) CostCurrencies(ctx context.Context, params models.CostParams) ([]string, error) {
+	periodStart, err := validation.ParseMonthYear(params.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	periodEnd, err := validation.ParseMonthYear(params.EndDate)
+	if err != nil {
+		return nil, err
+	}
 
+	where, args := costConditions(params, periodStart, periodEnd)
+	rows, err := r.db.Query(ctx, "SELECT DISTINCT currency FROM subscriptions WHERE "+where, args...)
 	if err != nil {
-		r.logger.Error("failed to calculate total cost", zap.Error(err))
+		r.logger.Error("failed to get subscription currencies", zap.Error(err))
 		return nil, err
 	}
+	defer rows.Close()
 
-	r.logger.Info("calculated total cost",
-		zap.String("user_id", userID),
-		zap.String("service_name", serviceName),
-		zap.Int64("total_cost", totalCost),
-	)
+	var currencies []string
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get subscription currencies", zap.Error(err))
+		return nil, err
+	}
+	return currencies, nil
+}
 
-	return &models.TotalCostResponse{
-		TotalCost: int(totalCost),
-		Count:     count,
-	}, nil
+// LinkService привязывает к сервису подписки без service_id, чьё нормализованное название
+// совпадает с одним из names, и приводит к name название уже привязанных подписок.
+// Каждая изменённая подписка получает событие в журнале.
+func (r *SubscriptionRepository) LinkService(ctx context.Context, serviceID, name string, names []string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE deleted_at IS NULL AND (
+			(service_id = $1 AND service_name <> $2)
+			OR (service_id IS NULL AND lower(regexp_replace(btrim(service_name), '\s+', ' ', 'g')) = ANY($3))
+		)
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, serviceID, name, names)
+	if err != nil {
+		r.logger.Error("failed to link subscriptions to service", zap.Error(err), zap.String("service_id", serviceID))
+		return 0, err
+	}
+	var linked []*models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		linked = append(linked, sub)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, before := range linked {
+		after := *before
+		after.ServiceID = &serviceID
+		after.ServiceName = name
+		after.Version++
+		after.UpdatedAt = now
+
+		_, err := tx.Exec(ctx, `
+			UPDATE subscriptions SET service_id = $1, service_name = $2, updated_at = $3, version = version + 1
+			WHERE id = $4
+		`, serviceID, name, now, before.ID)
+		if err == nil {
+			err = r.recordEvent(ctx, tx, models.EventUpdated, before, &after)
+		}
+		if err != nil {
+			r.logger.Error("failed to link subscription to service", zap.Error(err), zap.String("id", before.ID))
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	if len(linked) > 0 {
+		r.logger.Info("linked subscriptions to service", zap.String("service_id", serviceID), zap.Int("count", len(linked)))
+	}
+	return len(linked), nil
 }
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var (
+	ErrPriceChangeNotFound = errors.New("price change not found")
+	ErrPriceChangeExists   = errors.New("price change for this month already exists")
+)
+
+// ListPrices возвращает изменения цены подписки по возрастанию effective_from.
+func (r *SubscriptionRepository) ListPrices(ctx context.Context, subscriptionID string) ([]models.SubscriptionPrice, error) {
+	if uuid.Validate(subscriptionID) != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	query := `
+		SELECT effective_from, price, created_at
+		FROM subscription_prices
+		WHERE subscription_id = $1
+		ORDER BY effective_from
+	`
+
+	rows, err := r.db.Query(ctx, query, subscriptionID)
+	if err != nil {
+		r.logger.Error("failed to get subscription prices", zap.Error(err), zap.String("id", subscriptionID))
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []models.SubscriptionPrice{}
+	for rows.Next() {
+		price := models.SubscriptionPrice{SubscriptionID: subscriptionID}
+		var effectiveFrom time.Time
+		if err := rows.Scan(&effectiveFrom, &price.Price, &price.CreatedAt); err != nil {
+			return nil, err
+		}
+		price.EffectiveFrom = validation.FormatMonthYear(effectiveFrom)
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error("failed to get subscription prices", zap.Error(err), zap.String("id", subscriptionID))
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// AddPrice добавляет изменение цены с месяца input.EffectiveFrom.
+func (r *SubscriptionRepository) AddPrice(ctx context.Context, subscriptionID string, input models.SubscriptionPriceInput) (*models.SubscriptionPrice, error) {
+	if uuid.Validate(subscriptionID) != nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	effectiveFrom, err := validation.ParseMonthYear(input.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	version, err := subscriptionVersion(ctx, tx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	price := models.SubscriptionPrice{
+		SubscriptionID: subscriptionID,
+		EffectiveFrom:  input.EffectiveFrom,
+		Price:          input.Price,
+	}
+	err = tx.QueryRow(ctx, query, subscriptionID, effectiveFrom, int64(input.Price), time.Now()).Scan(&price.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		return nil, ErrPriceChangeExists
+	}
+	if err == nil {
+		err = insertEvent(ctx, tx, newPriceEvent(ctx, models.EventPriceAdded, subscriptionID, version, input.EffectiveFrom, nil, &input.Price))
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		r.logger.Error("failed to add subscription price", zap.Error(err), zap.String("id", subscriptionID))
+		return nil, err
+	}
+
+	r.logger.Info("subscription price added", zap.String("id", subscriptionID), zap.String("effective_from", input.EffectiveFrom))
+	return &price, nil
+}
+
+func (r *SubscriptionRepository) DeletePrice(ctx context.Context, subscriptionID, effectiveFrom string) error {
+	if uuid.Validate(subscriptionID) != nil {
+		return ErrPriceChangeNotFound
+	}
+	month, err := validation.ParseMonthYear(effectiveFrom)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	version, err := subscriptionVersion(ctx, tx, subscriptionID)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		return ErrPriceChangeNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var price models.Money
+	err = tx.QueryRow(ctx, "DELETE FROM subscription_prices WHERE subscription_id = $1 AND effective_from = $2 RETURNING price", subscriptionID, month).Scan(&price)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPriceChangeNotFound
+	}
+	if err == nil {
+		err = insertEvent(ctx, tx, newPriceEvent(ctx, models.EventPriceRemoved, subscriptionID, version, effectiveFrom, &price, nil))
+	}
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
+	if err != nil {
+		r.logger.Error("failed to delete subscription price", zap.Error(err), zap.String("id", subscriptionID))
+		return err
+	}
+
+	r.logger.Info("subscription price deleted", zap.String("id", subscriptionID), zap.String("effective_from", effectiveFrom))
+	return nil
+}
+
+// subscriptionVersion текущая версия подписки; блокирует подписку от удаления до конца транзакции.
+func subscriptionVersion(ctx context.Context, tx pgx.Tx, subscriptionID string) (int, error) {
+	var version int
+	err := tx.QueryRow(ctx, "SELECT version FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR SHARE", subscriptionID).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrSubscriptionNotFound
+	}
+	return version, err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+func (r *MemorySubscriptionRepository) ListPrices(ctx context.Context, subscriptionID string) ([]models.SubscriptionPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.activeSub(subscriptionID); !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	return append([]models.SubscriptionPrice{}, r.prices[subscriptionID]...), nil
+}
+
+func (r *MemorySubscriptionRepository) AddPrice(ctx context.Context, subscriptionID string, input models.SubscriptionPriceInput) (*models.SubscriptionPrice, error) {
+	if _, err := validation.ParseMonthYear(input.EffectiveFrom); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.activeSub(subscriptionID)
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	for _, p := range r.prices[subscriptionID] {
+		if p.EffectiveFrom == input.EffectiveFrom {
+			return nil, ErrPriceChangeExists
+		}
+	}
+
+	price := models.SubscriptionPrice{
+		SubscriptionID: subscriptionID,
+		EffectiveFrom:  input.EffectiveFrom,
+		Price:          input.Price,
+		CreatedAt:      time.Now(),
+	}
+	r.remember(subscriptionID)
+	prices := append(r.prices[subscriptionID], price)
+	sort.Slice(prices, func(i, j int) bool {
+		a, _ := validation.ParseMonthYear(prices[i].EffectiveFrom)
+		b, _ := validation.ParseMonthYear(prices[j].EffectiveFrom)
+		return a.Before(b)
+	})
+	r.prices[subscriptionID] = prices
+	r.appendEvent(newPriceEvent(ctx, models.EventPriceAdded, subscriptionID, sub.Version, input.EffectiveFrom, nil, &price.Price))
+
+	return &price, nil
+}
+
+func (r *MemorySubscriptionRepository) DeletePrice(ctx context.Context, subscriptionID, effectiveFrom string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.activeSub(subscriptionID)
+	if !ok {
+		return ErrPriceChangeNotFound
+	}
+	prices := r.prices[subscriptionID]
+	for i, p := range prices {
+		if p.EffectiveFrom == effectiveFrom {
+			r.remember(subscriptionID)
+			r.prices[subscriptionID] = append(prices[:i], prices[i+1:]...)
+			r.appendEvent(newPriceEvent(ctx, models.EventPriceRemoved, subscriptionID, sub.Version, effectiveFrom, &p.Price, nil))
+			return nil
+		}
+	}
+	return ErrPriceChangeNotFound
+}
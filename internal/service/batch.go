@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+var (
+	ErrInvalidBatchOperation = errors.New("invalid batch operation")
+	ErrBatchAborted          = errors.New("not applied: another operation of the atomic batch failed")
+)
+
+// BatchOutcome итог пакета; Items[i] относится к i-й операции запроса.
+type BatchOutcome struct {
+	Mode  string
+	Items []BatchItemOutcome
+}
+
+// BatchItemOutcome итог одной операции: подписка после create/update или ошибка.
+type BatchItemOutcome struct {
+	Subscription *models.Subscription
+	Err          error
+	previous     *models.Subscription // подписка до update/delete: бюджет прежнего владельца проверяется после пакета
+}
+
+// Batch выполняет операции пакета по порядку в одной транзакции. В режиме atomic первая ошибка
+// откатывает весь пакет, остальные операции получают ErrBatchAborted; в режиме partial
+// каждая операция выполняется в своей точке сохранения и ошибка откатывает только её.
+func (s *SubscriptionService) Batch(ctx context.Context, req models.BatchRequest) (*BatchOutcome, error) {
+	if req.Mode == "" {
+		req.Mode = models.BatchModeAtomic
+	}
+	if err := s.validator.StructCtx(ctx, req); err != nil {
+		s.logger.Warn("validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	outcome := &BatchOutcome{Mode: req.Mode, Items: make([]BatchItemOutcome, len(req.Operations))}
+	err := s.repo.WithinTx(ctx, func(repo repository.SubscriptionStore) error {
+		tx := s.withStore(repo)
+		for i, op := range req.Operations {
+			item := &outcome.Items[i]
+			if req.Mode == models.BatchModePartial {
+				item.Err = repo.WithinTx(ctx, func(repo repository.SubscriptionStore) error {
+					sub, previous, err := s.withStore(repo).applyOperation(ctx, op)
+					item.Subscription, item.previous = sub, previous
+					return err
+				})
+				if item.Err != nil {
+					item.Subscription, item.previous = nil, nil
+				}
+				continue
+			}
+
+			sub, previous, err := tx.applyOperation(ctx, op)
+			if err != nil {
+				return &RowError{Row: i + 1, Err: err}
+			}
+			item.Subscription, item.previous = sub, previous
+		}
+		return nil
+	})
+
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		for i := range outcome.Items {
+			outcome.Items[i] = BatchItemOutcome{Err: ErrBatchAborted}
+		}
+		outcome.Items[rowErr.Row-1].Err = rowErr.Err
+		s.logger.Warn("batch rolled back", zap.Int("operation", rowErr.Row-1), zap.Error(rowErr.Err))
+		return outcome, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]string) // user_id → последняя изменённая подписка
+	for _, item := range outcome.Items {
+		if item.previous != nil {
+			changed[item.previous.UserID] = item.previous.ID
+		}
+		if item.Subscription != nil {
+			changed[item.Subscription.UserID] = item.Subscription.ID
+		}
+	}
+	for userID, subscriptionID := range changed {
+		s.checkBudget(ctx, userID, subscriptionID)
+	}
+	return outcome, nil
+}
+
+// withStore копия сервиса, работающая с хранилищем транзакции. Бюджеты в ней не проверяются:
+// проверка видит только зафиксированные изменения, поэтому выполняется после транзакции.
+func (s *SubscriptionService) withStore(repo repository.SubscriptionStore) *SubscriptionService {
+	tx := *s
+	tx.repo = repo
+	tx.budgets = nil
+	return &tx
+}
+
+// applyOperation выполняет одну операцию пакета теми же методами, что и отдельные запросы.
+// Возвращает подписку после create/update и, для update и delete, подписку до операции.
+func (s *SubscriptionService) applyOperation(ctx context.Context, op models.BatchOperation) (*models.Subscription, *models.Subscription, error) {
+	if err := s.validator.StructCtx(ctx, op); err != nil {
+		return nil, nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	switch op.Op {
+	case models.BatchOpCreate:
+		var input models.CreateSubscriptionInput
+		if err := json.Unmarshal(op.Subscription, &input); err != nil {
+			return nil, nil, fmt.Errorf("%w: subscription: %w", ErrInvalidBatchOperation, err)
+		}
+		sub, err := s.Create(ctx, input)
+		return sub, nil, err
+	case models.BatchOpUpdate:
+		var input models.ReplaceSubscriptionInput
+		if err := json.Unmarshal(op.Subscription, &input); err != nil {
+			return nil, nil, fmt.Errorf("%w: subscription: %w", ErrInvalidBatchOperation, err)
+		}
+		// update может сменить user_id: владелец до изменения берётся заранее
+		previous, err := s.repo.GetByID(ctx, op.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		sub, err := s.Replace(ctx, op.ID, input, op.Version)
+		return sub, previous, err
+	default:
+		// после удаления подписка не читается, владелец для проверки бюджета берётся заранее
+		previous, err := s.repo.GetByID(ctx, op.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, previous, s.Delete(ctx, op.ID, op.Version)
+	}
+}
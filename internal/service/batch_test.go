@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	svc := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	existing, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := func(v interface{}) json.RawMessage {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	ops := []models.BatchOperation{
+		{Op: models.BatchOpCreate, Subscription: body(models.CreateSubscriptionInput{
+			ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: "07-2025",
+		})},
+		{Op: models.BatchOpUpdate, ID: existing.ID, Version: existing.Version, Subscription: body(models.ReplaceSubscriptionInput{
+			ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: "01-2025",
+			BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency, Category: "video",
+		})},
+		{Op: models.BatchOpCreate, Subscription: json.RawMessage(`{"service_name": "Spotify", "price": 100, "user_id": "` + testUserID + `", "start_date": "2025-07"}`)},
+		{Op: models.BatchOpDelete},
+	}
+
+	// в режиме atomic ошибка откатывает весь пакет
+	outcome, err := svc.Batch(ctx, models.BatchRequest{Operations: ops})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Mode != models.BatchModeAtomic {
+		t.Errorf("mode = %q, want atomic", outcome.Mode)
+	}
+	for i, item := range outcome.Items {
+		if i == 2 {
+			assertRule(t, item.Err, "month_year")
+		} else if !errors.Is(item.Err, ErrBatchAborted) {
+			t.Errorf("items[%d] error = %v, want ErrBatchAborted", i, item.Err)
+		}
+	}
+	list, err := svc.GetAll(ctx, models.ListSubscriptionsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Category != existing.Category {
+		t.Fatalf("atomic batch was not rolled back: %+v", list.Items)
+	}
+
+	// в режиме partial сохраняются успешные операции
+	outcome, err = svc.Batch(ctx, models.BatchRequest{Mode: models.BatchModePartial, Operations: ops})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created := outcome.Items[0]; created.Err != nil || created.Subscription == nil || created.Subscription.ServiceName != "Yandex Plus" {
+		t.Errorf("create = %+v", created)
+	}
+	if updated := outcome.Items[1]; updated.Err != nil || updated.Subscription.Category != "video" {
+		t.Errorf("update = %+v", updated)
+	}
+	assertRule(t, outcome.Items[2].Err, "month_year")
+	assertRule(t, outcome.Items[3].Err, "required_unless")
+
+	list, err = svc.GetAll(ctx, models.ListSubscriptionsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("subscriptions = %d, want 2", len(list.Items))
+	}
+
+	if _, err := svc.Batch(ctx, models.BatchRequest{Mode: "best-effort", Operations: ops}); err == nil {
+		t.Error("expected validation error for unknown mode")
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+// BudgetChecker проверка бюджета пользователя после изменения его подписок.
+type BudgetChecker interface {
+	Check(ctx context.Context, userID, subscriptionID string) (*models.BudgetAlert, error)
+}
+
+var _ BudgetChecker = (*BudgetService)(nil)
+
+// BudgetService месячные бюджеты пользователей. После изменения подписок расходы текущего месяца
+// сравниваются с бюджетом; при переходе через бюджет сохраняется предупреждение и отправляется на webhook.
+type BudgetService struct {
+	budgets   repository.BudgetStore
+	subs      repository.SubscriptionStore
+	rates     repository.ExchangeRateStore
+	client    *http.Client
+	allowAddr func(netip.Addr) bool // куда можно отправлять webhook; в тестах разрешается loopback
+	pending   chan struct{}         // занятые слоты отправки, не больше maxPendingWebhooks
+	validator *validator.Validate
+	logger    *zap.Logger
+}
+
+func NewBudgetService(budgets repository.BudgetStore, subs repository.SubscriptionStore, rates repository.ExchangeRateStore, logger *zap.Logger) *BudgetService {
+	s := &BudgetService{
+		budgets:   budgets,
+		subs:      subs,
+		rates:     rates,
+		allowAddr: publicAddr,
+		pending:   make(chan struct{}, maxPendingWebhooks),
+		validator: newValidator(logger),
+		logger:    logger,
+	}
+	s.client = newWebhookClient(func(addr netip.Addr) bool { return s.allowAddr(addr) })
+	return s
+}
+
+func (s *BudgetService) Get(ctx context.Context, userID string) (*models.Budget, error) {
+	if err := s.validateUserID(userID); err != nil {
+		return nil, err
+	}
+	budget, err := s.budgets.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// превышение прошлого месяца к текущему не относится
+	budget.Exceeded = budget.ExceededMonth == validation.FormatMonthYear(currentMonth())
+	return budget, nil
+}
+
+// Save создаёт или заменяет бюджет и сразу проверяет его: если расходы уже больше бюджета,
+// создаётся предупреждение.
+func (s *BudgetService) Save(ctx context.Context, userID string, input models.BudgetInput) (*models.Budget, error) {
+	if err := s.validateUserID(userID); err != nil {
+		return nil, err
+	}
+	if err := s.validator.StructCtx(ctx, input); err != nil {
+		s.logger.Warn("validation error", zap.Error(err))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if input.WebhookURL != "" {
+		if err := checkWebhookURL(ctx, input.WebhookURL, s.allowAddr); err != nil {
+			s.logger.Warn("webhook not allowed", zap.String("user_id", userID), zap.Error(err))
+			return nil, err
+		}
+	}
+	if input.Currency == "" {
+		input.Currency = models.BaseCurrency
+	}
+
+	budget, err := s.budgets.Save(ctx, userID, input)
+	if err != nil {
+		return nil, err
+	}
+	alert, err := s.Check(ctx, userID, "")
+	if err != nil {
+		s.logger.Warn("failed to check budget", zap.String("user_id", userID), zap.Error(err))
+	}
+	if alert != nil {
+		budget.Exceeded = true
+		budget.ExceededMonth = alert.Month
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) Delete(ctx context.Context, userID string) error {
+	if err := s.validateUserID(userID); err != nil {
+		return err
+	}
+	return s.budgets.Delete(ctx, userID)
+}
+
+func (s *BudgetService) ListAlerts(ctx context.Context, userID string) (*models.BudgetAlertList, error) {
+	if err := s.validateUserID(userID); err != nil {
+		return nil, err
+	}
+	alerts, err := s.budgets.ListAlerts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BudgetAlertList{Items: alerts}, nil
+}
+
+// Check считает расходы пользователя в текущем месяце так же, как total-cost с amortize=true,
+// и сравнивает их с бюджетом. Возвращает предупреждение, если бюджет только что оказался превышен;
+// без бюджета ничего не делает. subscriptionID — подписка, изменение которой вызвало проверку.
+func (s *BudgetService) Check(ctx context.Context, userID, subscriptionID string) (*models.BudgetAlert, error) {
+	budget, err := s.budgets.Get(ctx, userID)
+	if errors.Is(err, repository.ErrBudgetNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current := currentMonth()
+	month := validation.FormatMonthYear(current)
+	params := models.CostParams{
+		UserID:    userID,
+		StartDate: month,
+		EndDate:   month,
+		Amortize:  true,
+		Currency:  budget.Currency,
+	}
+	rates, err := loadCostRates(ctx, s.subs, s.rates, params, current, current)
+	if err != nil {
+		return nil, err
+	}
+	params.Rates = rates
+	total, err := s.subs.GetTotalCostForPeriod(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := s.budgets.SetExceeded(ctx, userID, total.TotalCost > budget.Amount, models.BudgetAlert{
+		Month:          month,
+		Spend:          total.TotalCost,
+		Amount:         budget.Amount,
+		Currency:       budget.Currency,
+		SubscriptionID: subscriptionID,
+	})
+	if err != nil || alert == nil {
+		return nil, err
+	}
+
+	s.logger.Info("budget exceeded", zap.String("user_id", userID), zap.String("spend", alert.Spend.String()), zap.String("budget", alert.Amount.String()))
+	if budget.WebhookURL != "" {
+		s.notifyAsync(budget.WebhookURL, *alert)
+	}
+	return alert, nil
+}
+
+// notifyAsync отправляет предупреждение в фоне: запрос уже обработан, отправка не должна его задерживать
+// и не отменяется вместе с ним. Если отправляется уже maxPendingWebhooks предупреждений, новое пропускается.
+func (s *BudgetService) notifyAsync(url string, alert models.BudgetAlert) {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		s.logger.Warn("budget alert not sent: too many pending webhooks", zap.String("user_id", alert.UserID))
+		return
+	}
+	go func() {
+		defer func() { <-s.pending }()
+		s.notify(url, alert)
+	}()
+}
+
+// notify отправляет предупреждение POST-запросом с телом BudgetAlert; ошибки только логируются.
+func (s *BudgetService) notify(url string, alert models.BudgetAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		s.logger.Error("failed to encode budget alert", zap.Error(err))
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Warn("invalid budget webhook", zap.String("user_id", alert.UserID), zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Warn("failed to send budget alert", zap.String("user_id", alert.UserID), zap.Error(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.Warn("budget webhook rejected alert", zap.String("user_id", alert.UserID), zap.Int("status", resp.StatusCode))
+	}
+}
+
+func (s *BudgetService) validateUserID(userID string) error {
+	if err := s.validator.Var(userID, "uuid"); err != nil {
+		return fmt.Errorf("%w: user_id must be a valid UUID", ErrInvalidQuery)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+func TestBudgetAlerts(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	rates := repository.NewMemoryExchangeRateRepository()
+	budgets := NewBudgetService(repository.NewMemoryBudgetRepository(), subRepo, rates, zap.NewNop())
+	budgets.allowAddr = func(netip.Addr) bool { return true } // webhook на loopback
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: rates, Services: repository.NewMemoryServiceRepository(), Budgets: budgets}, zap.NewNop())
+
+	delivered := make(chan models.BudgetAlert, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert models.BudgetAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Error(err)
+		}
+		delivered <- alert
+	}))
+	defer webhook.Close()
+
+	budget, err := budgets.Save(ctx, testUserID, models.BudgetInput{Amount: 150000, WebhookURL: webhook.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.Exceeded || budget.Currency != models.BaseCurrency {
+		t.Errorf("budget = %+v", budget)
+	}
+
+	month := validation.FormatMonthYear(time.Now())
+	create := func(name string, price models.Money) *models.Subscription {
+		sub, err := subs.Create(ctx, models.CreateSubscriptionInput{ServiceName: name, Price: price, UserID: testUserID, StartDate: month})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+	create("Netflix", 99900)
+	spotify := create("Spotify", 99900) // 1998.00 > 1500.00
+
+	select {
+	case alert := <-delivered:
+		if alert.SubscriptionID != spotify.ID || alert.Spend != 199800 || alert.Amount != 150000 || alert.Month != month {
+			t.Errorf("delivered alert = %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("alert was not sent to the webhook")
+	}
+
+	// пока бюджет превышен, новые подписки не создают предупреждений
+	okko := create("Okko", 10000)
+	if err := subs.Delete(ctx, okko.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := subs.Delete(ctx, spotify.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	// расходы вернулись в бюджет, следующее превышение — новое предупреждение
+	yandex := create("Yandex Plus", 60000)
+	<-delivered
+
+	alerts, err := budgets.ListAlerts(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Items) != 2 || alerts.Items[0].SubscriptionID != yandex.ID || alerts.Items[1].SubscriptionID != spotify.ID {
+		t.Fatalf("alerts = %+v", alerts.Items)
+	}
+
+	// бюджет ниже текущих расходов сразу превышен
+	budget, err = budgets.Save(ctx, testUserID, models.BudgetInput{Amount: 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !budget.Exceeded {
+		t.Error("budget below spend is not exceeded")
+	}
+
+	if err := budgets.Delete(ctx, testUserID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := budgets.Get(ctx, testUserID); !errors.Is(err, repository.ErrBudgetNotFound) {
+		t.Errorf("get deleted budget: %v", err)
+	}
+	if alerts, _ := budgets.ListAlerts(ctx, testUserID); len(alerts.Items) != 3 {
+		t.Errorf("alerts after budget delete = %d, want 3", len(alerts.Items))
+	}
+}
+
+func TestBudgetExceededResetsMonthly(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	budgetRepo := repository.NewMemoryBudgetRepository()
+	budgets := NewBudgetService(budgetRepo, subRepo, repository.NewMemoryExchangeRateRepository(), zap.NewNop())
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository(), Budgets: budgets}, zap.NewNop())
+
+	if _, err := budgets.Save(ctx, testUserID, models.BudgetInput{Amount: 50000}); err != nil {
+		t.Fatal(err)
+	}
+	// бюджет был превышен в прошлом месяце
+	previous := validation.FormatMonthYear(currentMonth().AddDate(0, -1, 0))
+	if alert, err := budgetRepo.SetExceeded(ctx, testUserID, true, models.BudgetAlert{Month: previous}); err != nil || alert == nil {
+		t.Fatalf("alert = %v, err = %v", alert, err)
+	}
+	budget, err := budgets.Get(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.Exceeded || budget.ExceededMonth != previous {
+		t.Errorf("budget = %+v, exceeded only in %s", budget, previous)
+	}
+
+	// первое превышение в новом месяце снова создаёт предупреждение
+	sub, err := subs.Create(ctx, models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: validation.FormatMonthYear(currentMonth())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := budgets.ListAlerts(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts.Items) != 2 || alerts.Items[0].SubscriptionID != sub.ID {
+		t.Fatalf("alerts = %+v", alerts.Items)
+	}
+	if budget, _ := budgets.Get(ctx, testUserID); !budget.Exceeded {
+		t.Errorf("budget = %+v, want exceeded in current month", budget)
+	}
+}
+
+func TestBudgetCheckedForPreviousOwner(t *testing.T) {
+	const otherUserID = "a4b3c2d1-0e9f-4a8b-9c7d-6e5f4a3b2c1d"
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	rates := repository.NewMemoryExchangeRateRepository()
+	budgets := NewBudgetService(repository.NewMemoryBudgetRepository(), subRepo, rates, zap.NewNop())
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: rates, Services: repository.NewMemoryServiceRepository(), Budgets: budgets, Rules: ValidationRules{AllowUserIDChange: true}}, zap.NewNop())
+
+	month := validation.FormatMonthYear(currentMonth())
+	sub, err := subs.Create(ctx, models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: month})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget, err := budgets.Save(ctx, testUserID, models.BudgetInput{Amount: 50000}); err != nil || !budget.Exceeded {
+		t.Fatalf("budget = %+v, err = %v", budget, err)
+	}
+
+	// подписка переходит другому пользователю: расходы прежнего владельца вернулись в бюджет
+	if _, err := subs.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: otherUserID, StartDate: month, BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency}, 0); err != nil {
+		t.Fatal(err)
+	}
+	budget, err := budgets.Get(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.Exceeded {
+		t.Errorf("budget of the previous owner = %+v, want not exceeded", budget)
+	}
+
+	// то же для update в пакете: подписка возвращается, затем снова уходит другому пользователю
+	if _, err := subs.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: month, BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if budget, _ := budgets.Get(ctx, testUserID); !budget.Exceeded {
+		t.Fatalf("budget = %+v, want exceeded", budget)
+	}
+	update, err := json.Marshal(models.ReplaceSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: otherUserID, StartDate: month, BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := subs.Batch(ctx, models.BatchRequest{Operations: []models.BatchOperation{{Op: models.BatchOpUpdate, ID: sub.ID, Subscription: update}}})
+	if err != nil || outcome.Items[0].Err != nil {
+		t.Fatalf("outcome = %+v, err = %v", outcome, err)
+	}
+	if budget, _ := budgets.Get(ctx, testUserID); budget.Exceeded {
+		t.Errorf("budget of the previous owner after batch update = %+v, want not exceeded", budget)
+	}
+}
+
+func TestBudgetCheckedAfterBatchDelete(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	rates := repository.NewMemoryExchangeRateRepository()
+	budgets := NewBudgetService(repository.NewMemoryBudgetRepository(), subRepo, rates, zap.NewNop())
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: rates, Services: repository.NewMemoryServiceRepository(), Budgets: budgets}, zap.NewNop())
+
+	sub, err := subs.Create(ctx, models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 99900, UserID: testUserID, StartDate: validation.FormatMonthYear(currentMonth())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget, err := budgets.Save(ctx, testUserID, models.BudgetInput{Amount: 50000}); err != nil || !budget.Exceeded {
+		t.Fatalf("budget = %+v, err = %v", budget, err)
+	}
+
+	outcome, err := subs.Batch(ctx, models.BatchRequest{Operations: []models.BatchOperation{{Op: models.BatchOpDelete, ID: sub.ID}}})
+	if err != nil || outcome.Items[0].Err != nil {
+		t.Fatalf("outcome = %+v, err = %v", outcome, err)
+	}
+	if budget, _ := budgets.Get(ctx, testUserID); budget.Exceeded {
+		t.Errorf("budget after batch delete = %+v, want not exceeded", budget)
+	}
+}
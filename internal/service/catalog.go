@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+var ErrUnknownService = errors.New("service_id does not reference a service in the catalog")
+
+// CatalogService управление каталогом сервисов. При создании и изменении сервиса
+// к нему привязываются подписки, чьё название совпадает с названием или синонимом сервиса.
+type CatalogService struct {
+	services  repository.ServiceStore
+	subs      repository.SubscriptionStore
+	validator *validator.Validate
+	logger    *zap.Logger
+}
+
+func NewCatalogService(services repository.ServiceStore, subs repository.SubscriptionStore, logger *zap.Logger) *CatalogService {
+	return &CatalogService{
+		services:  services,
+		subs:      subs,
+		validator: newValidator(logger),
+		logger:    logger,
+	}
+}
+
+func (s *CatalogService) List(ctx context.Context, filter models.ServiceFilter) (*models.ServiceList, error) {
+	services, err := s.services.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ServiceList{Items: services}, nil
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id string) (*models.Service, error) {
+	return s.services.GetByID(ctx, id)
+}
+
+func (s *CatalogService) Create(ctx context.Context, input models.ServiceInput) (*models.Service, error) {
+	input, err := s.prepareInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := s.services.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkSubscriptions(ctx, svc); err != nil {
+		// без привязки сервис не создаётся: иначе повторный запрос упрётся в занятое название
+		if delErr := s.services.Delete(ctx, svc.ID); delErr != nil {
+			s.logger.Error("failed to delete unlinked service", zap.Error(delErr), zap.String("service_id", svc.ID))
+		}
+		return nil, err
+	}
+	return svc, nil
+}
+
+// Update полностью заменяет сервис; привязанные подписки получают новое название.
+// При ошибке привязки сервис уже изменён, повторный запрос привяжет подписки.
+func (s *CatalogService) Update(ctx context.Context, id string, input models.ServiceInput) (*models.Service, error) {
+	input, err := s.prepareInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	svc, err := s.services.Update(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkSubscriptions(ctx, svc); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// Delete удаляет сервис, если на него не ссылается ни одна подписка. Удалённые подписки
+// можно восстановить, поэтому они держат сервис до окончательной очистки.
+func (s *CatalogService) Delete(ctx context.Context, id string) error {
+	if _, err := s.services.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	for _, deleted := range []bool{false, true} {
+		params := models.ListSubscriptionsParams{
+			SubscriptionFilter: models.SubscriptionFilter{ServiceID: id, Deleted: deleted},
+			Limit:              1,
+		}
+		list, err := s.subs.GetAll(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(list.Items) > 0 {
+			return repository.ErrServiceInUse
+		}
+	}
+
+	return s.services.Delete(ctx, id)
+}
+
+// prepareInput проверяет сервис и приводит его к виду для сохранения: без лишних пробелов,
+// пустых и повторяющихся синонимов, валюта по умолчанию RUB.
+func (s *CatalogService) prepareInput(ctx context.Context, input models.ServiceInput) (models.ServiceInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Category = strings.TrimSpace(input.Category)
+
+	aliases := []string{}
+	seen := map[string]bool{models.NormalizeServiceName(input.Name): true}
+	for _, alias := range input.Aliases {
+		alias = strings.TrimSpace(alias)
+		if key := models.NormalizeServiceName(alias); key != "" && !seen[key] {
+			seen[key] = true
+			aliases = append(aliases, alias)
+		}
+	}
+	input.Aliases = aliases
+
+	if err := s.validator.StructCtx(ctx, input); err != nil {
+		s.logger.Warn("validation error", zap.Error(err))
+		return input, fmt.Errorf("validation error: %w", err)
+	}
+
+	if input.Currency == "" {
+		input.Currency = models.BaseCurrency
+	}
+	return input, nil
+}
+
+// linkSubscriptions привязывает к сервису подписки с подходящим названием.
+func (s *CatalogService) linkSubscriptions(ctx context.Context, svc *models.Service) error {
+	if _, err := s.subs.LinkService(ctx, svc.ID, svc.Name, models.ServiceNames(svc.Name, svc.Aliases)); err != nil {
+		return fmt.Errorf("failed to link subscriptions to service: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+func TestCatalog_ResolvesSubscriptionService(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	serviceRepo := repository.NewMemoryServiceRepository()
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: serviceRepo}, zap.NewNop())
+	catalog := NewCatalogService(serviceRepo, subRepo, zap.NewNop())
+
+	// подписка, созданная до появления сервиса в каталоге
+	early, err := subs.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: " yandex  plus ", Price: 30000, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price := models.Money(39900)
+	svc, err := catalog.Create(ctx, models.ServiceInput{
+		Name: "Yandex Plus", Aliases: []string{"Яндекс Плюс", "yandex plus"}, DefaultPrice: &price,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Currency != models.BaseCurrency || len(svc.Aliases) != 1 {
+		t.Errorf("service = %+v, want RUB and one alias", svc)
+	}
+
+	linked, err := subs.GetByID(ctx, early.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if linked.ServiceID == nil || *linked.ServiceID != svc.ID || linked.ServiceName != "Yandex Plus" {
+		t.Errorf("existing subscription not linked: service_id=%v service_name=%q", linked.ServiceID, linked.ServiceName)
+	}
+
+	sub, err := subs.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "ЯНДЕКС плюс", UserID: testUserID, StartDate: "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ServiceID == nil || *sub.ServiceID != svc.ID || sub.ServiceName != "Yandex Plus" || sub.Price != price {
+		t.Errorf("alias not resolved: %+v", sub)
+	}
+
+	// название вне каталога сохраняется как есть, без цены подписку создать нельзя
+	_, err = subs.Create(ctx, models.CreateSubscriptionInput{ServiceName: "Kinopoisk", UserID: testUserID, StartDate: "07-2025"})
+	assertRule(t, err, "required")
+
+	_, err = subs.Create(ctx, models.CreateSubscriptionInput{
+		ServiceID: "9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d", UserID: testUserID, StartDate: "07-2025",
+	})
+	if !errors.Is(err, ErrUnknownService) {
+		t.Errorf("expected ErrUnknownService, got %v", err)
+	}
+
+	// новое название в патче отвязывает подписку от прежнего сервиса
+	patched, err := subs.Patch(ctx, sub.ID, []byte(`{"service_name": "Kinopoisk"}`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.ServiceID != nil || patched.ServiceName != "Kinopoisk" {
+		t.Errorf("patched service_id=%v service_name=%q, want unlinked Kinopoisk", patched.ServiceID, patched.ServiceName)
+	}
+
+	if _, err := catalog.Create(ctx, models.ServiceInput{Name: "Plus", Aliases: []string{"YANDEX PLUS"}}); !errors.Is(err, repository.ErrServiceNameTaken) {
+		t.Errorf("expected ErrServiceNameTaken, got %v", err)
+	}
+	if err := catalog.Delete(ctx, svc.ID); !errors.Is(err, repository.ErrServiceInUse) {
+		t.Errorf("expected ErrServiceInUse, got %v", err)
+	}
+}
+
+func TestCatalog_RenamePropagatesToSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	serviceRepo := repository.NewMemoryServiceRepository()
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: serviceRepo}, zap.NewNop())
+	catalog := NewCatalogService(serviceRepo, subRepo, zap.NewNop())
+
+	svc, err := catalog.Create(ctx, models.ServiceInput{Name: "Netflix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := subs.Create(ctx, models.CreateSubscriptionInput{
+		ServiceID: svc.ID, Price: 99900, UserID: testUserID, StartDate: "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := catalog.Update(ctx, svc.ID, models.ServiceInput{Name: "Netflix Premium", Aliases: []string{"Netflix"}}); err != nil {
+		t.Fatal(err)
+	}
+	renamed, err := subs.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.ServiceName != "Netflix Premium" || renamed.Version != sub.Version+1 {
+		t.Errorf("service_name=%q version=%d, want Netflix Premium and version %d", renamed.ServiceName, renamed.Version, sub.Version+1)
+	}
+
+	if err := subs.Delete(ctx, sub.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	// удалённую подписку можно восстановить, сервис освобождается только после очистки
+	if err := catalog.Delete(ctx, svc.ID); !errors.Is(err, repository.ErrServiceInUse) {
+		t.Errorf("expected ErrServiceInUse, got %v", err)
+	}
+	if _, err := subs.Purge(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.Delete(ctx, svc.ID); err != nil {
+		t.Errorf("delete unused service: %v", err)
+	}
+}
+
+func TestCatalog_ServiceNameFilter(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	serviceRepo := repository.NewMemoryServiceRepository()
+	subs := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: serviceRepo}, zap.NewNop())
+	catalog := NewCatalogService(serviceRepo, subRepo, zap.NewNop())
+
+	svc, err := catalog.Create(ctx, models.ServiceInput{Name: "Yandex Plus", Aliases: []string{"Яндекс Плюс", " ", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.Aliases) != 1 {
+		t.Errorf("aliases = %q, want only Яндекс Плюс", svc.Aliases)
+	}
+	for _, input := range []models.CreateSubscriptionInput{
+		{ServiceID: svc.ID, Price: 40000, UserID: testUserID, StartDate: "01-2025"},
+		{ServiceName: "Kinopoisk", Price: 30000, UserID: testUserID, StartDate: "01-2025"},
+	} {
+		if _, err := subs.Create(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// синоним из каталога выбирает подписки сервиса, название вне каталога сравнивается точно
+	for name, want := range map[string]int{"яндекс  плюс": 1, "Kinopoisk": 1, "kinopoisk": 0} {
+		list, err := subs.GetAll(ctx, models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{ServiceName: name}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Items) != want {
+			t.Errorf("service_name=%q: %d subscriptions, want %d", name, len(list.Items), want)
+		}
+	}
+	total, err := subs.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "01-2025", ServiceName: "Яндекс Плюс"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.TotalCost != 40000 {
+		t.Errorf("TotalCost = %d, want 40000", total.TotalCost)
+	}
+}
+
+// failingLinkStore хранилище подписок, в котором не удаётся привязать подписки к сервису.
+type failingLinkStore struct {
+	repository.SubscriptionStore
+}
+
+func (failingLinkStore) LinkService(ctx context.Context, serviceID, name string, names []string) (int, error) {
+	return 0, errors.New("connection lost")
+}
+
+func TestCatalog_CreateFailsWhenLinkFails(t *testing.T) {
+	ctx := context.Background()
+	serviceRepo := repository.NewMemoryServiceRepository()
+	catalog := NewCatalogService(serviceRepo, failingLinkStore{repository.NewMemorySubscriptionRepository(zap.NewNop())}, zap.NewNop())
+
+	if _, err := catalog.Create(ctx, models.ServiceInput{Name: "Netflix"}); err == nil {
+		t.Fatal("expected link error")
+	}
+	if _, err := serviceRepo.FindByName(ctx, "Netflix"); !errors.Is(err, repository.ErrServiceNotFound) {
+		t.Errorf("service must not stay in the catalog without linked subscriptions, got %v", err)
+	}
+}
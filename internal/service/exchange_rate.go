@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+var ErrBaseCurrencyRate = errors.New("rate of the base currency " + models.BaseCurrency + " is always 1")
+
+// ExchangeRateService управление курсами валют для пересчёта стоимости.
+type ExchangeRateService struct {
+	repo      repository.ExchangeRateStore
+	validator *validator.Validate
+	logger    *zap.Logger
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateStore, logger *zap.Logger) *ExchangeRateService {
+	return &ExchangeRateService{
+		repo:      repo,
+		validator: newValidator(logger),
+		logger:    logger,
+	}
+}
+
+func (s *ExchangeRateService) List(ctx context.Context, currency string) (*models.ExchangeRateList, error) {
+	if currency != "" {
+		if err := s.validator.Var(currency, "currency"); err != nil {
+			return nil, fmt.Errorf("%w: currency must be an ISO 4217 code with two decimal places", ErrInvalidQuery)
+		}
+	}
+
+	rates, err := s.repo.List(ctx, currency)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ExchangeRateList{Items: rates}, nil
+}
+
+// Save создаёт или заменяет курс валюты на месяц.
+func (s *ExchangeRateService) Save(ctx context.Context, input models.ExchangeRateInput) error {
+	if err := s.validateRate(ctx, input); err != nil {
+		return err
+	}
+	return s.repo.Upsert(ctx, []models.ExchangeRateInput{input})
+}
+
+// Import сохраняет набор курсов целиком; при ошибке в любой строке не сохраняется ничего.
+func (s *ExchangeRateService) Import(ctx context.Context, rates []models.ExchangeRateInput) (*models.ExchangeRateImportResult, error) {
+	for i, rate := range rates {
+		if err := s.validateRate(ctx, rate); err != nil {
+			return nil, &RowError{Row: i + 1, Err: err}
+		}
+	}
+
+	if err := s.repo.Upsert(ctx, rates); err != nil {
+		return nil, err
+	}
+	return &models.ExchangeRateImportResult{Imported: len(rates)}, nil
+}
+
+func (s *ExchangeRateService) Delete(ctx context.Context, currency, effectiveFrom string) error {
+	if !validation.IsValidMonthYear(effectiveFrom) {
+		return ErrInvalidDateFormat
+	}
+	return s.repo.Delete(ctx, currency, effectiveFrom)
+}
+
+func (s *ExchangeRateService) validateRate(ctx context.Context, input models.ExchangeRateInput) error {
+	if err := s.validator.StructCtx(ctx, input); err != nil {
+		s.logger.Warn("validation error", zap.Error(err))
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if input.Currency == models.BaseCurrency {
+		return ErrBaseCurrencyRate
+	}
+	return nil
+}
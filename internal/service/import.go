@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+var ErrInvalidImportValue = errors.New("invalid value")
+
+// ImportOptions параметры импорта файла.
+type ImportOptions struct {
+	DryRun bool // только проверить строки
+	// DecimalComma запятая в цене — десятичный разделитель ("999,00"); допустимо, когда колонки
+	// разделены не запятой
+	DecimalComma bool
+}
+
+// ImportOutcome итог импорта; Row в Errors — номер строки файла.
+type ImportOutcome struct {
+	DryRun   bool
+	Rows     int
+	Valid    int
+	Imported int
+	Errors   []RowError
+}
+
+// Import проверяет строки файла по тем же правилам, что и Create. С opts.DryRun только сообщает об ошибках,
+// иначе сохраняет все подписки в одной транзакции — и только если ни в одной строке нет ошибок.
+func (s *SubscriptionService) Import(ctx context.Context, rows []models.SubscriptionImportRow, opts ImportOptions) (*ImportOutcome, error) {
+	outcome := &ImportOutcome{DryRun: opts.DryRun, Rows: len(rows)}
+	inputs := make([]models.CreateSubscriptionInput, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		input, err := importInput(row, opts.DecimalComma)
+		if err == nil {
+			input, err = s.prepareCreate(ctx, input)
+		}
+		if err != nil {
+			outcome.Errors = append(outcome.Errors, RowError{Row: row.Line, Err: err})
+			continue
+		}
+		inputs = append(inputs, input)
+		lines = append(lines, row.Line)
+	}
+	outcome.Valid = len(inputs)
+	if opts.DryRun || len(outcome.Errors) > 0 {
+		return outcome, nil
+	}
+
+	created := make(map[string]string) // user_id → последняя созданная подписка, для проверки бюджета
+	err := s.repo.WithinTx(ctx, func(repo repository.SubscriptionStore) error {
+		for i, input := range inputs {
+			sub, err := repo.Create(ctx, input)
+			if err != nil {
+				return &RowError{Row: lines[i], Err: err}
+			}
+			created[sub.UserID] = sub.ID
+		}
+		return nil
+	})
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		outcome.Errors = append(outcome.Errors, *rowErr)
+		return outcome, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	outcome.Imported = len(inputs)
+	s.logger.Info("subscriptions imported", zap.Int("count", outcome.Imported))
+	for userID, subscriptionID := range created {
+		s.checkBudget(ctx, userID, subscriptionID)
+	}
+	return outcome, nil
+}
+
+// importInput переводит строку файла в тело POST /subscriptions. Даты принимаются в MM-YYYY
+// и ISO 8601 (YYYY-MM, YYYY-MM-DD); неверный формат отклонит проверка month_year.
+// С decimalComma цена может быть записана с запятой: "999,00".
+func importInput(row models.SubscriptionImportRow, decimalComma bool) (models.CreateSubscriptionInput, error) {
+	values := row.Values
+	input := models.CreateSubscriptionInput{
+		ServiceID:     values["service_id"],
+		ServiceName:   values["service_name"],
+		UserID:        values["user_id"],
+		StartDate:     validation.NormalizeMonthYear(values["start_date"]),
+		EndDate:       validation.NormalizeMonthYear(values["end_date"]),
+		BillingPeriod: strings.ToLower(values["billing_period"]),
+		Currency:      strings.ToUpper(values["currency"]),
+		Category:      values["category"],
+	}
+
+	if price := values["price"]; price != "" {
+		if decimalComma {
+			price = strings.Replace(price, ",", ".", 1)
+		}
+		money, err := models.ParseMoney(price)
+		if err != nil {
+			return input, fmt.Errorf("%w: price must be a decimal with at most two fractional digits", ErrInvalidImportValue)
+		}
+		input.Price = money
+	}
+	if interval := values["billing_interval"]; interval != "" {
+		n, err := strconv.Atoi(interval)
+		if err != nil {
+			return input, fmt.Errorf("%w: billing_interval must be an integer", ErrInvalidImportValue)
+		}
+		input.BillingInterval = n
+	}
+	if tags := values["tags"]; tags != "" {
+		input.Tags = strings.Split(tags, models.CSVTagSeparator)
+	}
+	return input, nil
+}
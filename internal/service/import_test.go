@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	row := func(line int, values map[string]string) models.SubscriptionImportRow {
+		base := map[string]string{"service_name": "Yandex Plus", "price": "399.90", "user_id": testUserID, "start_date": "07-2025"}
+		for k, v := range values {
+			base[k] = v
+		}
+		return models.SubscriptionImportRow{Line: line, Values: base}
+	}
+	rows := []models.SubscriptionImportRow{
+		row(2, map[string]string{"start_date": "2025-07-15", "tags": "Family|music"}),
+		row(3, map[string]string{"start_date": "07.2025"}),
+		row(4, map[string]string{"price": "abc"}),
+	}
+
+	outcome, err := svc.Import(ctx, rows, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Rows != 3 || outcome.Valid != 1 || len(outcome.Errors) != 2 {
+		t.Fatalf("dry run = %+v", outcome)
+	}
+	if outcome.Errors[0].Row != 3 {
+		t.Errorf("first error row = %d, want 3", outcome.Errors[0].Row)
+	}
+	assertRule(t, outcome.Errors[0].Err, "month_year")
+
+	// с ошибками ничего не сохраняется
+	outcome, err = svc.Import(ctx, rows, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Imported != 0 || len(outcome.Errors) != 2 {
+		t.Errorf("import with errors = %+v", outcome)
+	}
+
+	outcome, err = svc.Import(ctx, rows[:1], ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Imported != 1 {
+		t.Fatalf("imported = %d, want 1", outcome.Imported)
+	}
+	list, err := svc.GetAll(ctx, models.ListSubscriptionsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].StartDate != "07-2025" || list.Items[0].Price != 39990 || len(list.Items[0].Tags) != 2 {
+		t.Errorf("imported subscriptions = %+v", list.Items)
+	}
+}
+
+func TestImport_DecimalComma(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	// строка файла с разделителем ";" из TestReadSubscriptionsCSV
+	rows := []models.SubscriptionImportRow{{Line: 2, Values: map[string]string{
+		"service_name": "Netflix", "price": "999,00", "user_id": testUserID, "start_date": "2025-07",
+	}}}
+
+	outcome, err := svc.Import(ctx, rows, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Errors) != 1 || !errors.Is(outcome.Errors[0].Err, ErrInvalidImportValue) {
+		t.Errorf("decimal comma without the option: %+v", outcome)
+	}
+
+	outcome, err = svc.Import(ctx, rows, ImportOptions{DecimalComma: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Imported != 1 {
+		t.Fatalf("import = %+v", outcome)
+	}
+	list, err := svc.GetAll(ctx, models.ListSubscriptionsParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Price != 99900 {
+		t.Errorf("imported subscriptions = %+v", list.Items)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"em-internship/internal/models"
+)
+
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// applyMergePatch применяет JSON Merge Patch (RFC 7396): объекты сливаются рекурсивно,
+// null удаляет ключ, любое другое значение заменяет текущее целиком.
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// replaceInputFrom текущее состояние подписки в виде тела полной замены.
+func replaceInputFrom(sub models.Subscription) models.ReplaceSubscriptionInput {
+	input := models.ReplaceSubscriptionInput{
+		ServiceName:     sub.ServiceName,
+		Price:           sub.Price,
+		UserID:          sub.UserID,
+		StartDate:       sub.StartDate,
+		BillingPeriod:   sub.BillingPeriod,
+		BillingInterval: sub.BillingInterval,
+		Currency:        sub.Currency,
+		Category:        sub.Category,
+		Tags:            sub.Tags,
+	}
+	if sub.ServiceID != nil {
+		input.ServiceID = *sub.ServiceID
+	}
+	if sub.EndDate != nil {
+		input.EndDate = *sub.EndDate
+	}
+	return input
+}
+
+// patchSubscription применяет merge patch к подписке и возвращает итоговое тело полной замены.
+// Неизвестные поля и значения неверного типа считаются ошибкой патча.
+func patchSubscription(sub models.Subscription, patch []byte) (models.ReplaceSubscriptionInput, error) {
+	var input models.ReplaceSubscriptionInput
+
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return input, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return input, fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	current, err := json.Marshal(replaceInputFrom(sub))
+	if err != nil {
+		return input, err
+	}
+	var target interface{}
+	if err := json.Unmarshal(current, &target); err != nil {
+		return input, err
+	}
+
+	// новое название без service_id заново ищется в каталоге, а не остаётся привязанным к прежнему сервису
+	if patchObj := patchDoc.(map[string]interface{}); patchObj["service_name"] != nil {
+		if _, ok := patchObj["service_id"]; !ok {
+			delete(target.(map[string]interface{}), "service_id")
+		}
+	}
+
+	merged, err := json.Marshal(applyMergePatch(target, patchDoc))
+	if err != nil {
+		return input, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&input); err != nil {
+		return input, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+	return input, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+var (
+	ErrPriceEditNotAllowed      = errors.New("price and currency of a started subscription cannot be replaced, add a price change via POST /subscriptions/{id}/prices")
+	ErrPriceChangeOutsidePeriod = errors.New("price change is outside the new subscription period, delete it first")
+)
+
+// ListPrices возвращает историю цен подписки: цену с start_date и все последующие изменения.
+func (s *SubscriptionService) ListPrices(ctx context.Context, id string) (*models.SubscriptionPriceList, error) {
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.repo.ListPrices(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.SubscriptionPrice, 0, len(changes)+1)
+	items = append(items, models.SubscriptionPrice{
+		SubscriptionID: sub.ID,
+		EffectiveFrom:  sub.StartDate,
+		Price:          sub.Price,
+		CreatedAt:      sub.CreatedAt,
+	})
+	items = append(items, changes...)
+
+	return &models.SubscriptionPriceList{Items: items}, nil
+}
+
+// AddPrice добавляет изменение цены с месяца input.EffectiveFrom; прошлые месяцы считаются по прежней цене.
+func (s *SubscriptionService) AddPrice(ctx context.Context, id string, input models.SubscriptionPriceInput) (*models.SubscriptionPrice, error) {
+	if err := s.validator.StructCtx(ctx, input); err != nil {
+		s.logger.Warn("validation error", zap.Error(err), zap.String("id", id))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validator.StructCtx(ctx, priceChangeCandidate{SubscriptionPriceInput: input, sub: *sub}); err != nil {
+		s.logger.Warn("validation error", zap.Error(err), zap.String("id", id))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	price, err := s.repo.AddPrice(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	s.checkBudget(ctx, sub.UserID, sub.ID)
+	return price, nil
+}
+
+func (s *SubscriptionService) DeletePrice(ctx context.Context, id, effectiveFrom string) error {
+	if !validation.IsValidMonthYear(effectiveFrom) {
+		return ErrInvalidDateFormat
+	}
+	if err := s.repo.DeletePrice(ctx, id, effectiveFrom); err != nil {
+		return err
+	}
+	if s.budgets != nil {
+		sub, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		s.checkBudget(ctx, sub.UserID, sub.ID)
+	}
+	return nil
+}
+
+// checkPriceHistory не даёт замене подписки переписать уже посчитанные расходы: цену и валюту подписки,
+// начавшейся до текущего месяца, меняют через историю цен, а новые start_date и end_date должны оставлять
+// все изменения цены внутри периода подписки.
+func (s *SubscriptionService) checkPriceHistory(ctx context.Context, current *models.Subscription, input models.ReplaceSubscriptionInput) error {
+	start, err := validation.ParseMonthYear(input.StartDate)
+	if err != nil {
+		return err
+	}
+	currentStart, err := validation.ParseMonthYear(current.StartDate)
+	if err != nil {
+		return err
+	}
+	// расходы уже посчитаны с прежнего start_date: перенос начала на текущий месяц их не отменяет
+	month := currentMonth()
+	if (input.Price != current.Price || input.Currency != current.Currency) && (start.Before(month) || currentStart.Before(month)) {
+		return ErrPriceEditNotAllowed
+	}
+
+	currentEnd := ""
+	if current.EndDate != nil {
+		currentEnd = *current.EndDate
+	}
+	if input.StartDate == current.StartDate && input.EndDate == currentEnd {
+		return nil
+	}
+
+	changes, err := s.repo.ListPrices(ctx, current.ID)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		from, err := validation.ParseMonthYear(change.EffectiveFrom)
+		if err != nil {
+			return err
+		}
+		outside := !from.After(start)
+		if input.EndDate != "" {
+			end, err := validation.ParseMonthYear(input.EndDate)
+			if err != nil {
+				return err
+			}
+			outside = outside || from.After(end)
+		}
+		if outside {
+			return fmt.Errorf("%w: %s", ErrPriceChangeOutsidePeriod, change.EffectiveFrom)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+// Renewal подписка пользователя, которая ещё будет продлеваться, и её цена на ближайшее продление.
+type Renewal struct {
+	Subscription *models.Subscription
+	Price        models.Money // цена текущего месяца или start_date, если подписка ещё не началась
+}
+
+// Renewals возвращает подписки пользователя, которые не удалены и не закончились к текущему месяцу,
+// по возрастанию start_date.
+func (s *SubscriptionService) Renewals(ctx context.Context, userID string) ([]Renewal, error) {
+	if err := s.validator.Var(userID, "uuid"); err != nil {
+		return nil, fmt.Errorf("%w: user_id must be a valid UUID", ErrInvalidQuery)
+	}
+
+	month := currentMonth()
+
+	var renewals []Renewal
+	filter := models.SubscriptionFilter{UserID: userID}
+	err := s.repo.Export(ctx, filter, "start_date", func(sub *models.Subscription) error {
+		if sub.EndDate != nil {
+			end, err := validation.ParseMonthYear(*sub.EndDate)
+			if err != nil {
+				return err
+			}
+			if end.Before(month) {
+				return nil
+			}
+		}
+		renewals = append(renewals, Renewal{Subscription: sub})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// цены читаются после выгрузки: пока она идёт, соединение занято курсором
+	for i, renewal := range renewals {
+		price, err := s.priceFrom(ctx, renewal.Subscription, month)
+		if err != nil {
+			return nil, err
+		}
+		renewals[i].Price = price
+	}
+	return renewals, nil
+}
+
+// priceFrom цена подписки в месяце month, но не раньше её start_date.
+func (s *SubscriptionService) priceFrom(ctx context.Context, sub *models.Subscription, month time.Time) (models.Money, error) {
+	start, err := validation.ParseMonthYear(sub.StartDate)
+	if err != nil {
+		return 0, err
+	}
+	if start.After(month) {
+		month = start
+	}
+
+	changes, err := s.repo.ListPrices(ctx, sub.ID)
+	if err != nil {
+		return 0, err
+	}
+	price := sub.Price
+	for _, change := range changes {
+		from, err := validation.ParseMonthYear(change.EffectiveFrom)
+		if err != nil {
+			return 0, err
+		}
+		if from.After(month) {
+			break
+		}
+		price = change.Price
+	}
+	return price, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+func TestRenewals(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository(), Rules: ValidationRules{MaxFutureYears: 100}}, zap.NewNop())
+
+	create := func(input models.CreateSubscriptionInput) *models.Subscription {
+		input.UserID = testUserID
+		sub, err := svc.Create(ctx, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+	netflix := create(models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 99900, StartDate: "01-2020"})
+	if _, err := svc.AddPrice(ctx, netflix.ID, models.SubscriptionPriceInput{EffectiveFrom: "01-2021", Price: 109900}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AddPrice(ctx, netflix.ID, models.SubscriptionPriceInput{EffectiveFrom: "01-2099", Price: 129900}); err != nil {
+		t.Fatal(err)
+	}
+	future := create(models.CreateSubscriptionInput{ServiceName: "Yandex Plus", Price: 39990, StartDate: "01-2090"})
+	create(models.CreateSubscriptionInput{ServiceName: "Spotify", Price: 16900, StartDate: "01-2020", EndDate: "12-2020"})
+	deleted := create(models.CreateSubscriptionInput{ServiceName: "Okko", Price: 29900, StartDate: "01-2020"})
+	if err := svc.Delete(ctx, deleted.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	renewals, err := svc.Renewals(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(renewals) != 2 {
+		t.Fatalf("renewals = %d, want 2", len(renewals))
+	}
+	if renewals[0].Subscription.ID != netflix.ID || renewals[0].Price != 109900 {
+		t.Errorf("renewals[0] = %s %s, want Netflix 1099.00", renewals[0].Subscription.ServiceName, renewals[0].Price)
+	}
+	if renewals[1].Subscription.ID != future.ID || renewals[1].Price != 39990 {
+		t.Errorf("renewals[1] = %s %s, want Yandex Plus 399.90", renewals[1].Subscription.ServiceName, renewals[1].Price)
+	}
+
+	if _, err := svc.Renewals(ctx, "not-a-uuid"); err == nil {
+		t.Error("expected error for invalid user_id")
+	}
+}
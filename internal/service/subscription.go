@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
@@ -13,60 +16,397 @@ import (
 	"em-internship/internal/validation"
 )
 
-var ErrInvalidDateFormat = errors.New("invalid date format: use MM-YYYY")
+// maxCostPeriodMonths наибольший период расчёта стоимости: разбивка по месяцам не должна расти без границ
+const maxCostPeriodMonths = 120
+
+var (
+	ErrInvalidDateFormat = validation.ErrInvalidMonthYear
+	ErrInvalidQuery      = errors.New("invalid query parameters")
+)
 
 type SubscriptionService struct {
-	repo      *repository.SubscriptionRepository
+	repo      repository.SubscriptionStore
+	rates     repository.ExchangeRateStore
+	services  repository.ServiceStore
+	budgets   BudgetChecker // nil — бюджеты не проверяются
 	validator *validator.Validate
 	logger    *zap.Logger
 }
 
-func NewSubscriptionService(repo *repository.SubscriptionRepository, logger *zap.Logger) *SubscriptionService {
-	v := validator.New()
-	if err := validation.RegisterMonthYear(v); err != nil {
-		logger.Warn("failed to register month_year validator", zap.Error(err))
-	}
+// SubscriptionOptions зависимости и настройки SubscriptionService помимо хранилища подписок.
+type SubscriptionOptions struct {
+	Rates    repository.ExchangeRateStore
+	Services repository.ServiceStore
+	Budgets  BudgetChecker // nil — бюджеты не проверяются
+	Rules    ValidationRules
+}
+
+func NewSubscriptionService(repo repository.SubscriptionStore, opts SubscriptionOptions, logger *zap.Logger) *SubscriptionService {
+	v := newValidator(logger)
+	registerSubscriptionRules(v, opts.Rules.withDefaults())
+	registerPriceRules(v)
+
 	return &SubscriptionService{
 		repo:      repo,
+		rates:     opts.Rates,
+		services:  opts.Services,
+		budgets:   opts.Budgets,
 		validator: v,
 		logger:    logger,
 	}
 }
 
 func (s *SubscriptionService) Create(ctx context.Context, input models.CreateSubscriptionInput) (*models.Subscription, error) {
+	input, err := s.prepareCreate(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	s.checkBudget(ctx, sub.UserID, sub.ID)
+	return sub, nil
+}
+
+// prepareCreate проверяет новую подписку и дополняет её данными каталога и значениями по умолчанию.
+func (s *SubscriptionService) prepareCreate(ctx context.Context, input models.CreateSubscriptionInput) (models.CreateSubscriptionInput, error) {
 	if err := s.validator.StructCtx(ctx, input); err != nil {
 		s.logger.Warn("validation error", zap.Error(err))
-		return nil, fmt.Errorf("validation error: %w", err)
+		return input, fmt.Errorf("validation error: %w", err)
 	}
-
-	return s.repo.Create(ctx, input)
+	input, err := s.resolveService(ctx, input)
+	if err != nil {
+		return input, err
+	}
+	input = withInputDefaults(input)
+	if err := s.validator.StructCtx(ctx, newCandidate(input)); err != nil {
+		s.logger.Warn("validation error", zap.Error(err))
+		return input, fmt.Errorf("validation error: %w", err)
+	}
+	return input, nil
 }
 
 func (s *SubscriptionService) GetByID(ctx context.Context, id string) (*models.Subscription, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *SubscriptionService) GetAll(ctx context.Context, limit, offset int) (*models.SubscriptionList, error) {
-	if limit <= 0 { // простая проверка на дурака
-		limit = 15
-	} else if limit > 99 {
-		limit = 99
+func (s *SubscriptionService) GetAll(ctx context.Context, params models.ListSubscriptionsParams) (*models.SubscriptionList, error) {
+	if params.Limit <= 0 { // простая проверка на дурака
+		params.Limit = 15
+	} else if params.Limit > 99 {
+		params.Limit = 99
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+	params, err := s.prepareListParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.repo.GetAll(ctx, params)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	return list, err
+}
+
+// Export передаёт fn все подписки с фильтрами и сортировкой списка, без ограничения limit.
+func (s *SubscriptionService) Export(ctx context.Context, params models.ListSubscriptionsParams, fn func(*models.Subscription) error) error {
+	params, err := s.prepareListParams(ctx, params)
+	if err != nil {
+		return err
+	}
+	return s.repo.Export(ctx, params.SubscriptionFilter, params.Sort, fn)
+}
+
+// prepareListParams нормализует и проверяет фильтры и сортировку списка.
+func (s *SubscriptionService) prepareListParams(ctx context.Context, params models.ListSubscriptionsParams) (models.ListSubscriptionsParams, error) {
+	params.Category = models.NormalizeLabel(params.Category)
+	params.Tags = models.NormalizeTags(params.Tags)
+
+	if err := s.validator.StructCtx(ctx, params); err != nil {
+		s.logger.Warn("invalid list parameters", zap.Error(err))
+		return params, fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return params, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidQuery)
+	}
+	var err error
+	params.ServiceID, params.ServiceName, err = s.resolveServiceFilter(ctx, params.ServiceID, params.ServiceName)
+	return params, err
+}
+
+// resolveServiceFilter заменяет фильтр по service_name фильтром по сервису каталога, если название
+// или синоним есть в каталоге: так находятся все подписки сервиса, под каким бы названием их ни создали.
+// Название не из каталога остаётся точным фильтром.
+func (s *SubscriptionService) resolveServiceFilter(ctx context.Context, serviceID, serviceName string) (string, string, error) {
+	if serviceName == "" || serviceID != "" {
+		return serviceID, serviceName, nil
+	}
+	svc, err := s.services.FindByName(ctx, serviceName)
+	if errors.Is(err, repository.ErrServiceNotFound) {
+		return serviceID, serviceName, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return svc.ID, "", nil
+}
+
+// Replace полностью заменяет подписку (PUT).
+// ifMatch — ожидаемая клиентом версия (If-Match), 0 — без проверки.
+func (s *SubscriptionService) Replace(ctx context.Context, id string, input models.ReplaceSubscriptionInput, ifMatch int) (*models.Subscription, error) {
+	current, err := s.getForChange(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.GetAll(ctx, limit, offset)
+	return s.replace(ctx, current, input)
 }
 
-func (s *SubscriptionService) Update(ctx context.Context, id string, input models.UpdateSubscriptionInput) (*models.Subscription, error) {
-	return s.repo.Update(ctx, id, input)
+// Patch применяет к подписке JSON Merge Patch (PATCH); null в патче очищает поле.
+func (s *SubscriptionService) Patch(ctx context.Context, id string, patch []byte, ifMatch int) (*models.Subscription, error) {
+	current, err := s.getForChange(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := patchSubscription(*current, patch)
+	if err != nil {
+		s.logger.Warn("invalid merge patch", zap.Error(err), zap.String("id", id))
+		return nil, err
+	}
+
+	return s.replace(ctx, current, input)
+}
+
+// replace проверяет новое состояние подписки относительно текущего и только затем сохраняет его.
+func (s *SubscriptionService) replace(ctx context.Context, current *models.Subscription, input models.ReplaceSubscriptionInput) (*models.Subscription, error) {
+	if err := s.validator.StructCtx(ctx, input); err != nil {
+		s.logger.Warn("validation error", zap.Error(err), zap.String("id", current.ID))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	resolved, err := s.resolveService(ctx, models.CreateSubscriptionInput(input))
+	if err != nil {
+		return nil, err
+	}
+	input = models.ReplaceSubscriptionInput(withInputDefaults(resolved))
+	candidate := newCandidate(models.CreateSubscriptionInput(input))
+	candidate.previousUserID = current.UserID
+	if err := s.validator.StructCtx(ctx, candidate); err != nil {
+		s.logger.Warn("validation error", zap.Error(err), zap.String("id", current.ID))
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	if err := s.checkPriceHistory(ctx, current, input); err != nil {
+		return nil, err
+	}
+
+	// версия прочитанной подписки: если её успели изменить, запись не перезапишет чужие правки
+	sub, err := s.repo.Update(ctx, current.ID, input, current.Version)
+	if err != nil {
+		return nil, err
+	}
+	s.checkBudget(ctx, sub.UserID, sub.ID)
+	if current.UserID != sub.UserID {
+		// расходы прежнего владельца уменьшились
+		s.checkBudget(ctx, current.UserID, sub.ID)
+	}
+	return sub, nil
 }
 
-func (s *SubscriptionService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+// resolveService связывает подписку с каталогом: по service_id или, если он не задан, по названию
+// или синониму service_name. Найденный сервис задаёт название подписки, а без цены — цену и валюту
+// по умолчанию. Название, которого нет в каталоге, сохраняется как есть.
+func (s *SubscriptionService) resolveService(ctx context.Context, input models.CreateSubscriptionInput) (models.CreateSubscriptionInput, error) {
+	var svc *models.Service
+	var err error
+	if input.ServiceID != "" {
+		svc, err = s.services.GetByID(ctx, input.ServiceID)
+		if errors.Is(err, repository.ErrServiceNotFound) {
+			return input, ErrUnknownService
+		}
+	} else {
+		svc, err = s.services.FindByName(ctx, input.ServiceName)
+		if errors.Is(err, repository.ErrServiceNotFound) {
+			input.ServiceName = strings.TrimSpace(input.ServiceName)
+			return input, nil
+		}
+	}
+	if err != nil {
+		return input, err
+	}
+
+	input.ServiceID = svc.ID
+	input.ServiceName = svc.Name
+	if input.Category == "" {
+		input.Category = svc.Category
+	}
+	if input.Price == 0 && svc.DefaultPrice != nil && (input.Currency == "" || input.Currency == svc.Currency) {
+		input.Price = *svc.DefaultPrice
+		input.Currency = svc.Currency
+	}
+	return input, nil
+}
+
+// getForChange читает подписку перед изменением и сверяет версию с If-Match.
+func (s *SubscriptionService) getForChange(ctx context.Context, id string, ifMatch int) (*models.Subscription, error) {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ifMatch != 0 && current.Version != ifMatch {
+		return nil, repository.ErrVersionMismatch
+	}
+	return current, nil
+}
+
+// Delete мягко удаляет подписку; ifMatch — ожидаемая версия, 0 — без проверки.
+// Восстановить подписку можно до очистки через Purge.
+func (s *SubscriptionService) Delete(ctx context.Context, id string, ifMatch int) error {
+	if s.budgets == nil {
+		return s.repo.Delete(ctx, id, ifMatch)
+	}
+
+	// после удаления подписка не читается, пользователь для проверки бюджета берётся заранее
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id, ifMatch); err != nil {
+		return err
+	}
+	s.checkBudget(ctx, sub.UserID, sub.ID)
+	return nil
+}
+
+// Restore возвращает мягко удалённую подписку в списки и расчёты; для неудалённой —
+// repository.ErrSubscriptionNotDeleted.
+func (s *SubscriptionService) Restore(ctx context.Context, id string) (*models.Subscription, error) {
+	sub, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.checkBudget(ctx, sub.UserID, sub.ID)
+	return sub, nil
+}
+
+// checkBudget проверяет бюджет пользователя после сохранённого изменения. Ошибка проверки
+// только логируется: изменение подписки уже сохранено.
+func (s *SubscriptionService) checkBudget(ctx context.Context, userID, subscriptionID string) {
+	if s.budgets == nil {
+		return
+	}
+	if _, err := s.budgets.Check(ctx, userID, subscriptionID); err != nil {
+		s.logger.Warn("failed to check budget", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// Purge окончательно удаляет подписки, удалённые больше retention назад.
+func (s *SubscriptionService) Purge(ctx context.Context, retention time.Duration) (*models.PurgeResult, error) {
+	before := time.Now().Add(-retention)
+	purged, err := s.repo.Purge(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PurgeResult{Purged: purged, Before: before}, nil
+}
+
+func (s *SubscriptionService) GetTotalCostForPeriod(ctx context.Context, params models.CostParams) (*models.TotalCostResponse, error) {
+	params, err := s.prepareCostParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTotalCostForPeriod(ctx, params)
+}
+
+// GetCostBreakdown разбивает стоимость за период по группам; по умолчанию — по месяцам.
+func (s *SubscriptionService) GetCostBreakdown(ctx context.Context, params models.CostParams) (*models.CostBreakdownResponse, error) {
+	switch params.GroupBy {
+	case "":
+		params.GroupBy = repository.GroupByMonth
+	case repository.GroupByMonth, repository.GroupByService, repository.GroupByUser, repository.GroupByCategory:
+	default:
+		return nil, fmt.Errorf("%w: group_by must be one of: month, service, user, category", ErrInvalidQuery)
+	}
+
+	params, err := s.prepareCostParams(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCostBreakdown(ctx, params)
+}
+
+// currentMonth первое число текущего месяца в UTC.
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// prepareCostParams проверяет общие параметры расчёта стоимости и загружает курсы валют.
+func (s *SubscriptionService) prepareCostParams(ctx context.Context, params models.CostParams) (models.CostParams, error) {
+	start, err := validation.ParseMonthYear(params.StartDate)
+	if err != nil {
+		return params, ErrInvalidDateFormat
+	}
+	end, err := validation.ParseMonthYear(params.EndDate)
+	if err != nil {
+		return params, ErrInvalidDateFormat
+	}
+	if start.After(end) {
+		return params, fmt.Errorf("%w: start_date is after end_date", ErrInvalidQuery)
+	}
+	if months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1; months > maxCostPeriodMonths {
+		return params, fmt.Errorf("%w: period must be at most %d months", ErrInvalidQuery, maxCostPeriodMonths)
+	}
+
+	if params.ServiceID != "" {
+		if err := s.validator.Var(params.ServiceID, "uuid"); err != nil {
+			return params, fmt.Errorf("%w: service_id must be a valid UUID", ErrInvalidQuery)
+		}
+	}
+	params.ServiceID, params.ServiceName, err = s.resolveServiceFilter(ctx, params.ServiceID, params.ServiceName)
+	if err != nil {
+		return params, err
+	}
+
+	if params.Currency == "" {
+		params.Currency = models.BaseCurrency
+	} else if err := s.validator.Var(params.Currency, "currency"); err != nil {
+		return params, fmt.Errorf("%w: currency must be an ISO 4217 code with two decimal places", ErrInvalidQuery)
+	}
+
+	rates, err := loadCostRates(ctx, s.repo, s.rates, params, start, end)
+	if err != nil {
+		return params, err
+	}
+	params.Rates = rates
+	return params, nil
+}
+
+// loadCostRates курсы для расчёта стоимости с params: только валюты расчёта и попадающих в него подписок
+// и только за месяцы [start, end].
+func loadCostRates(ctx context.Context, subs repository.SubscriptionStore, rates repository.ExchangeRateStore, params models.CostParams, start, end time.Time) ([]models.ExchangeRate, error) {
+	currencies, err := subs.CostCurrencies(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(currencies, params.Currency) {
+		currencies = append(currencies, params.Currency)
+	}
+	return rates.ListForPeriod(ctx, currencies, start, end)
 }
 
-func (s *SubscriptionService) GetTotalCostForPeriod(ctx context.Context, userID, serviceName, startDate, endDate string) (*models.TotalCostResponse, error) {
-	if !validation.IsValidMonthYear(startDate) || !validation.IsValidMonthYear(endDate) {
-		return nil, ErrInvalidDateFormat
+// History журнал изменений подписки от старых событий к новым; доступен и после удаления подписки.
+func (s *SubscriptionService) History(ctx context.Context, id string) (*models.SubscriptionHistory, error) {
+	events, err := s.repo.ListEvents(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.GetTotalCostForPeriod(ctx, userID, serviceName, startDate, endDate)
+	return &models.SubscriptionHistory{Items: events}, nil
 }
@@ -3,16 +3,25 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	"go.uber.org/zap"
 
+	"em-internship/internal/models"
 	"em-internship/internal/repository"
+	"em-internship/internal/validation"
 )
 
+const testUserID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
 func TestGetTotalCostForPeriod_InvalidDateFormat(t *testing.T) {
 	logger := zap.NewNop()
-	svc := NewSubscriptionService((*repository.SubscriptionRepository)(nil), logger)
+	svc := NewSubscriptionService((*repository.SubscriptionRepository)(nil), SubscriptionOptions{Rates: (*repository.ExchangeRateRepository)(nil), Services: (*repository.ServiceRepository)(nil)}, logger)
 	ctx := context.Background()
 
 	tests := []struct {
@@ -27,7 +36,7 @@ func TestGetTotalCostForPeriod_InvalidDateFormat(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := svc.GetTotalCostForPeriod(ctx, "", "", tt.startDate, tt.endDate)
+			_, err := svc.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: tt.startDate, EndDate: tt.endDate})
 			if err == nil {
 				t.Fatal("expected error")
 			}
@@ -37,3 +46,430 @@ func TestGetTotalCostForPeriod_InvalidDateFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCostBreakdown_InvalidPeriod(t *testing.T) {
+	svc := NewSubscriptionService((*repository.SubscriptionRepository)(nil), SubscriptionOptions{Rates: (*repository.ExchangeRateRepository)(nil), Services: (*repository.ServiceRepository)(nil)}, zap.NewNop())
+	ctx := context.Background()
+
+	for _, period := range [][2]string{{"12-2025", "01-2025"}, {"01-0000", "12-9999"}, {"01-2015", "02-2025"}} {
+		_, err := svc.GetCostBreakdown(ctx, models.CostParams{StartDate: period[0], EndDate: period[1]})
+		if !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("period %s..%s: expected ErrInvalidQuery, got %v", period[0], period[1], err)
+		}
+	}
+}
+
+func TestCreate_ValidationError(t *testing.T) {
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	_, err := svc.Create(context.Background(), models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserID:      testUserID,
+		StartDate:   "2025-07",
+	})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+}
+
+func TestGetAll_InvalidQuery(t *testing.T) {
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+	minPrice, maxPrice := models.Money(500), models.Money(100)
+
+	tests := []struct {
+		name   string
+		params models.ListSubscriptionsParams
+	}{
+		{"unknown sort", models.ListSubscriptionsParams{Sort: "user_id"}},
+		{"invalid active_in", models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{ActiveIn: "2025-01"}}},
+		{"invalid user_id", models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{UserID: "42"}}},
+		{"min greater than max", models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{MinPrice: &minPrice, MaxPrice: &maxPrice}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.GetAll(context.Background(), tt.params)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreate_CrossFieldRules(t *testing.T) {
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+	tooOld := fmt.Sprintf("01-%d", time.Now().Year()-100)
+
+	tests := []struct {
+		name     string
+		input    models.CreateSubscriptionInput
+		wantRule string
+	}{
+		{"end before start", models.CreateSubscriptionInput{StartDate: "07-2025", EndDate: "06-2025"}, "not_before_start"},
+		{"start too far in the past", models.CreateSubscriptionInput{StartDate: tooOld}, "year_range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.input.ServiceName = "Yandex Plus"
+			tt.input.Price = 400
+			tt.input.UserID = testUserID
+
+			_, err := svc.Create(context.Background(), tt.input)
+			assertRule(t, err, tt.wantRule)
+		})
+	}
+}
+
+func TestPatch_ValidatesMergedSubscription(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025", EndDate: "12-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Patch(ctx, sub.ID, []byte(`{"start_date": "01-2026"}`), 0)
+	assertRule(t, err, "not_before_start")
+
+	_, err = svc.Patch(ctx, sub.ID, []byte(`{"price": -1}`), 0)
+	assertRule(t, err, "gt")
+
+	_, err = svc.Patch(ctx, sub.ID, []byte(`{"service_name": null}`), 0)
+	assertRule(t, err, "required_without")
+
+	otherUser := "8f8f0a4e-0c2f-4a53-9b8c-6a2f4b0b6f11"
+	_, err = svc.Patch(ctx, sub.ID, []byte(`{"user_id": "`+otherUser+`"}`), 0)
+	assertRule(t, err, "immutable")
+
+	permissive := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository(), Rules: ValidationRules{AllowUserIDChange: true}}, zap.NewNop())
+	sub, err = permissive.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := permissive.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: otherUser, StartDate: "07-2025",
+		BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency,
+	}, 0); err != nil {
+		t.Errorf("expected user_id change to be permitted, got %v", err)
+	}
+}
+
+func TestPatch_MergeSemantics(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025", EndDate: "12-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := svc.Patch(ctx, sub.ID, []byte(`{"category": "music", "end_date": null}`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Category != "music" || patched.EndDate != nil || patched.ServiceName != "Yandex Plus" {
+		t.Errorf("Patch() = %+v", patched)
+	}
+
+	for _, patch := range []string{`not json`, `[1, 2]`, `{"id": "x"}`, `{"price": "5.001"}`} {
+		if _, err := svc.Patch(ctx, sub.ID, []byte(patch), 0); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("Patch(%s): expected ErrInvalidPatch, got %v", patch, err)
+		}
+	}
+}
+
+func TestCreate_BillingPeriod(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.BillingPeriod != models.BillingPeriodMonth || sub.BillingInterval != 1 {
+		t.Errorf("default billing = %s/%d, want month/1", sub.BillingPeriod, sub.BillingInterval)
+	}
+
+	patched, err := svc.Patch(ctx, sub.ID, []byte(`{"billing_period": "year"}`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.BillingPeriod != models.BillingPeriodYear || patched.BillingInterval != 1 {
+		t.Errorf("patched billing = %s/%d, want year/1", patched.BillingPeriod, patched.BillingInterval)
+	}
+
+	_, err = svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025", BillingPeriod: "daily",
+	})
+	assertRule(t, err, "oneof")
+}
+
+func TestGetTotalCostForPeriod_Currency(t *testing.T) {
+	ctx := context.Background()
+	rateRepo := repository.NewMemoryExchangeRateRepository()
+	rates := NewExchangeRateService(rateRepo, zap.NewNop())
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: rateRepo, Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	_, err := rates.Import(ctx, []models.ExchangeRateInput{
+		{Currency: "USD", EffectiveFrom: "01-2025", Rate: "90"},
+		{Currency: "EUR", EffectiveFrom: "01-2025", Rate: "0"},
+	})
+	var rowErr *RowError
+	if !errors.As(err, &rowErr) || rowErr.Row != 2 {
+		t.Fatalf("expected error in row 2, got %v", err)
+	}
+	if list, _ := rates.List(ctx, ""); len(list.Items) != 0 {
+		t.Fatalf("import must be atomic, saved %+v", list.Items)
+	}
+	if err := rates.Save(ctx, models.ExchangeRateInput{Currency: "USD", EffectiveFrom: "01-2025", Rate: "90"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "ChatGPT", Price: 20, Currency: "USD", UserID: testUserID, StartDate: "01-2025",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "01-2025",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rub, err := svc.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "02-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rub.Currency != "RUB" || rub.TotalCost != 2*(20*90+400) {
+		t.Errorf("total = %d %s, want %d RUB", rub.TotalCost, rub.Currency, 2*(20*90+400))
+	}
+
+	if _, err := svc.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "02-2025", Currency: "EUR"}); !errors.Is(err, repository.ErrMissingExchangeRate) {
+		t.Errorf("expected ErrMissingExchangeRate, got %v", err)
+	}
+	if _, err := svc.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "02-2025", Currency: "rubles"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestAddPrice(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: "01-2025", EndDate: "12-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "01-2025", Price: 50000})
+	assertRule(t, err, "after_start")
+	_, err = svc.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "01-2026", Price: 50000})
+	assertRule(t, err, "not_after_end")
+
+	if _, err := svc.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "07-2025", Price: 50000}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.AddPrice(ctx, sub.ID, models.SubscriptionPriceInput{EffectiveFrom: "07-2025", Price: 60000}); !errors.Is(err, repository.ErrPriceChangeExists) {
+		t.Errorf("expected ErrPriceChangeExists, got %v", err)
+	}
+
+	history, err := svc.ListPrices(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Items) != 2 || history.Items[0].EffectiveFrom != "01-2025" || history.Items[1].Price != 50000 {
+		t.Errorf("ListPrices() = %+v", history.Items)
+	}
+
+	total, err := svc.GetTotalCostForPeriod(ctx, models.CostParams{StartDate: "01-2025", EndDate: "12-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.TotalCost != 6*40000+6*50000 {
+		t.Errorf("TotalCost = %d, want %d", total.TotalCost, 6*40000+6*50000)
+	}
+
+	// замена не переписывает посчитанные расходы: цена меняется только через историю цен,
+	// а даты не могут оставить изменения цены за пределами подписки
+	for patch, want := range map[string]error{
+		`{"price": "450"}`:          ErrPriceEditNotAllowed,
+		`{"currency": "USD"}`:       ErrPriceEditNotAllowed,
+		`{"start_date": "07-2025"}`: ErrPriceChangeOutsidePeriod,
+		`{"end_date": "06-2025"}`:   ErrPriceChangeOutsidePeriod,
+	} {
+		if _, err := svc.Patch(ctx, sub.ID, []byte(patch), 0); !errors.Is(err, want) {
+			t.Errorf("Patch(%s): expected %v, got %v", patch, want, err)
+		}
+	}
+	if _, err := svc.Patch(ctx, sub.ID, []byte(`{"end_date": "07-2025"}`), 0); err != nil {
+		t.Errorf("Patch(end_date) keeping price changes inside the period: %v", err)
+	}
+
+	// у ещё не начавшейся подписки прошлых расходов нет, цену можно заменить
+	start := validation.FormatMonthYear(time.Now().UTC().AddDate(1, 0, 0))
+	future, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 40000, UserID: testUserID, StartDate: start,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched, err := svc.Patch(ctx, future.ID, []byte(`{"price": "450"}`), 0); err != nil || patched.Price != 45000 {
+		t.Errorf("Patch(price) of a future subscription = %+v, %v", patched, err)
+	}
+
+	// перенос start_date на текущий месяц вместе с ценой тоже переписал бы посчитанные расходы
+	started, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Okko", Price: 40000, UserID: testUserID, StartDate: "01-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := models.ReplaceSubscriptionInput{
+		ServiceName: "Okko", Price: 45000, UserID: testUserID, StartDate: validation.FormatMonthYear(time.Now().UTC()),
+		BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency,
+	}
+	if _, err := svc.Replace(ctx, started.ID, moved, 0); !errors.Is(err, ErrPriceEditNotAllowed) {
+		t.Errorf("Replace(start_date, price): expected ErrPriceEditNotAllowed, got %v", err)
+	}
+
+	if err := svc.DeletePrice(ctx, sub.ID, "07-2025"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeletePrice(ctx, sub.ID, "07-2025"); !errors.Is(err, repository.ErrPriceChangeNotFound) {
+		t.Errorf("expected ErrPriceChangeNotFound, got %v", err)
+	}
+}
+
+func TestReplace_RequiresAllFields(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025", EndDate: "12-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{Price: 400}, 0)
+	assertRule(t, err, "required")
+
+	// без значений по умолчанию: PUT без периода оплаты и валюты отклоняется, а не сбрасывает их
+	_, err = svc.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+	}, 0)
+	assertRule(t, err, "required")
+
+	replaced, err := svc.Replace(ctx, sub.ID, models.ReplaceSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+		BillingPeriod: models.BillingPeriodMonth, BillingInterval: 1, Currency: models.BaseCurrency,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.EndDate != nil {
+		t.Errorf("expected end_date to be cleared, got %v", *replaced.EndDate)
+	}
+}
+
+func assertRule(t *testing.T, err error, rule string) {
+	t.Helper()
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	for _, fe := range validationErrs {
+		if fe.Tag() == rule {
+			return
+		}
+	}
+	t.Errorf("expected %q violation, got %v", rule, validationErrs)
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Yandex Plus", Price: 400, UserID: testUserID, StartDate: "07-2025",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Version != 1 {
+		t.Fatalf("initial version = %d, want 1", sub.Version)
+	}
+
+	patched, err := svc.Patch(ctx, sub.ID, []byte(`{"category": "music"}`), sub.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Version != 2 {
+		t.Errorf("version after patch = %d, want 2", patched.Version)
+	}
+
+	// второй клиент всё ещё держит версию 1
+	if _, err := svc.Patch(ctx, sub.ID, []byte(`{"category": "video"}`), sub.Version); !errors.Is(err, repository.ErrVersionMismatch) {
+		t.Errorf("stale patch: expected ErrVersionMismatch, got %v", err)
+	}
+	if err := svc.Delete(ctx, sub.ID, sub.Version); !errors.Is(err, repository.ErrVersionMismatch) {
+		t.Errorf("stale delete: expected ErrVersionMismatch, got %v", err)
+	}
+	if err := svc.Delete(ctx, sub.ID, patched.Version); err != nil {
+		t.Errorf("delete with current version: %v", err)
+	}
+}
+
+func TestCreate_CategoryAndTags(t *testing.T) {
+	ctx := context.Background()
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	serviceRepo := repository.NewMemoryServiceRepository()
+	svc := NewSubscriptionService(subRepo, SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: serviceRepo}, zap.NewNop())
+
+	if _, err := NewCatalogService(serviceRepo, subRepo, zap.NewNop()).Create(ctx, models.ServiceInput{Name: "Netflix", Category: "Streaming"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sub, err := svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "netflix", Price: 99900, UserID: testUserID, StartDate: "07-2025",
+		Tags: []string{" Family ", "work", "family"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Category != "streaming" || !slices.Equal(sub.Tags, []string{"family", "work"}) {
+		t.Errorf("category = %q, tags = %v, want streaming and [family work]", sub.Category, sub.Tags)
+	}
+
+	patched, err := svc.Patch(ctx, sub.ID, []byte(`{"category": "Video", "tags": ["kids"]}`), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Category != "video" || !slices.Equal(patched.Tags, []string{"kids"}) {
+		t.Errorf("patched category = %q, tags = %v", patched.Category, patched.Tags)
+	}
+
+	list, err := svc.GetAll(ctx, models.ListSubscriptionsParams{SubscriptionFilter: models.SubscriptionFilter{Tags: []string{"KIDS"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 {
+		t.Errorf("tag filter returned %d items, want 1", len(list.Items))
+	}
+
+	_, err = svc.Create(ctx, models.CreateSubscriptionInput{
+		ServiceName: "Spotify", Price: 29900, UserID: testUserID, StartDate: "07-2025", Tags: []string{strings.Repeat("a", 51)},
+	})
+	assertRule(t, err, "max")
+}
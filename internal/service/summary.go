@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+// summaryEndingMonths подписка «скоро заканчивается», если end_date не позже текущего месяца плюс столько месяцев
+const summaryEndingMonths = 1
+
+// Summary сводка по подпискам пользователя на текущий месяц; суммы считаются так же, как total-cost,
+// в валюте currency (пусто — RUB).
+func (s *SubscriptionService) Summary(ctx context.Context, userID, currency string) (*models.UserSummary, error) {
+	if err := s.validator.Var(userID, "uuid"); err != nil {
+		return nil, fmt.Errorf("%w: user_id must be a valid UUID", ErrInvalidQuery)
+	}
+
+	now := time.Now().UTC()
+	month := validation.FormatMonthYear(now)
+	params, err := s.prepareCostParams(ctx, models.CostParams{UserID: userID, StartDate: month, EndDate: month, Currency: currency})
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.UserSummary{UserID: userID, Month: month, Currency: params.Currency, EndingSoon: []models.Subscription{}}
+
+	endingBefore := time.Date(now.Year(), now.Month()+summaryEndingMonths+1, 1, 0, 0, 0, 0, time.UTC)
+	err = s.repo.Export(ctx, models.SubscriptionFilter{UserID: userID, ActiveIn: month}, "start_date", func(sub *models.Subscription) error {
+		summary.ActiveSubscriptions++
+		if sub.EndDate == nil {
+			return nil
+		}
+		end, err := validation.ParseMonthYear(*sub.EndDate)
+		if err != nil {
+			return err
+		}
+		if end.Before(endingBefore) {
+			summary.EndingSoon = append(summary.EndingSoon, *sub)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	monthly := params
+	monthly.GroupBy = repository.GroupByService
+	monthly.Amortize = true
+	breakdown, err := s.repo.GetCostBreakdown(ctx, monthly)
+	if err != nil {
+		return nil, err
+	}
+	summary.MonthlySpend = breakdown.TotalCost
+	for i, bucket := range breakdown.Items {
+		if bucket.Amount > 0 && (summary.MostExpensiveService == nil || bucket.Amount > summary.MostExpensiveService.Amount) {
+			summary.MostExpensiveService = &breakdown.Items[i]
+		}
+	}
+
+	// курсы нужны за весь год: валюты и курсы прошлых месяцев могут отличаться от текущих
+	yearly, err := s.prepareCostParams(ctx, models.CostParams{
+		UserID:    userID,
+		StartDate: validation.FormatMonthYear(time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)),
+		EndDate:   month,
+		Currency:  currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.GetTotalCostForPeriod(ctx, yearly)
+	if err != nil {
+		return nil, err
+	}
+	summary.YearSpend = total.TotalCost
+	return summary, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+	"em-internship/internal/validation"
+)
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: repository.NewMemoryExchangeRateRepository(), Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	inputs := []models.CreateSubscriptionInput{
+		{ServiceName: "Netflix", Price: 99900, StartDate: validation.FormatMonthYear(time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC))},
+		{ServiceName: "Yandex Plus", Price: 120000, BillingPeriod: models.BillingPeriodYear, StartDate: validation.FormatMonthYear(month), EndDate: validation.FormatMonthYear(month.AddDate(0, 1, 0))},
+		{ServiceName: "Spotify", Price: 16900, StartDate: "01-2020", EndDate: "12-2020"},
+	}
+	for _, input := range inputs {
+		input.UserID = testUserID
+		if _, err := svc.Create(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := svc.Summary(ctx, testUserID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.ActiveSubscriptions != 2 || summary.Currency != models.BaseCurrency {
+		t.Errorf("summary = %+v", summary)
+	}
+	if summary.MonthlySpend != 109900 { // 999.00 + 1200.00 / 12
+		t.Errorf("monthly spend = %s, want 1099.00", summary.MonthlySpend)
+	}
+	if want := models.Money(99900*int(now.Month()) + 120000); summary.YearSpend != want {
+		t.Errorf("year spend = %s, want %s", summary.YearSpend, want)
+	}
+	if summary.MostExpensiveService == nil || summary.MostExpensiveService.Key != "Netflix" {
+		t.Errorf("most expensive = %+v, want Netflix", summary.MostExpensiveService)
+	}
+	if len(summary.EndingSoon) != 1 || summary.EndingSoon[0].ServiceName != "Yandex Plus" {
+		t.Errorf("ending soon = %+v", summary.EndingSoon)
+	}
+
+	if _, err := svc.Summary(ctx, "not-a-uuid", ""); err == nil {
+		t.Error("expected error for invalid user_id")
+	}
+}
+
+func TestSummary_YearSpendUsesRatesOfEachMonth(t *testing.T) {
+	ctx := context.Background()
+	rates := repository.NewMemoryExchangeRateRepository()
+	svc := NewSubscriptionService(repository.NewMemorySubscriptionRepository(zap.NewNop()), SubscriptionOptions{Rates: rates, Services: repository.NewMemoryServiceRepository()}, zap.NewNop())
+
+	now := time.Now().UTC()
+	january := validation.FormatMonthYear(time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC))
+	// курс сменился в текущем месяце: январские расходы считаются по прежнему курсу
+	err := rates.Upsert(ctx, []models.ExchangeRateInput{
+		{Currency: "USD", EffectiveFrom: january, Rate: "90"},
+		{Currency: "USD", EffectiveFrom: validation.FormatMonthYear(now), Rate: "95"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(ctx, models.CreateSubscriptionInput{ServiceName: "Netflix", Price: 1000, Currency: "USD", UserID: testUserID, StartDate: january}); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := svc.Summary(ctx, testUserID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.MonthlySpend != 95000 { // 10.00 USD по 95
+		t.Errorf("monthly spend = %s, want 950.00", summary.MonthlySpend)
+	}
+	if want := models.Money(90000*(int(now.Month())-1) + 95000); summary.YearSpend != want {
+		t.Errorf("year spend = %s, want %s", summary.YearSpend, want)
+	}
+}
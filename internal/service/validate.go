@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/validation"
+)
+
+const (
+	defaultMaxPastYears   = 50
+	defaultMaxFutureYears = 10
+)
+
+// newValidator валидатор с именами полей как в API и кастомными тегами.
+func newValidator(logger *zap.Logger) *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(validation.FieldName)
+	if err := validation.RegisterMonthYear(v); err != nil {
+		logger.Warn("failed to register month_year validator", zap.Error(err))
+	}
+	if err := validation.RegisterPositiveDecimal(v); err != nil {
+		logger.Warn("failed to register positive_decimal validator", zap.Error(err))
+	}
+	if err := validation.RegisterCurrency(v); err != nil {
+		logger.Warn("failed to register currency validator", zap.Error(err))
+	}
+	return v
+}
+
+// RowError ошибка в одной строке пакетной операции; Row начинается с 1.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationRules бизнес-правила, которые проверяются для итогового состояния подписки.
+type ValidationRules struct {
+	MaxPastYears      int  // start_date не раньше, чем столько лет назад
+	MaxFutureYears    int  // start_date не позже, чем через столько лет
+	AllowUserIDChange bool // разрешено ли переносить подписку на другого пользователя
+}
+
+func (r ValidationRules) withDefaults() ValidationRules {
+	if r.MaxPastYears <= 0 {
+		r.MaxPastYears = defaultMaxPastYears
+	}
+	if r.MaxFutureYears <= 0 {
+		r.MaxFutureYears = defaultMaxFutureYears
+	}
+	return r
+}
+
+// subscriptionCandidate подписка в том виде, в котором она будет сохранена.
+// previousUserID — владелец до изменения, пусто при создании.
+type subscriptionCandidate struct {
+	models.Subscription
+	previousUserID string
+}
+
+// registerSubscriptionRules добавляет в валидатор межполевые правила для subscriptionCandidate.
+// Нарушения попадают в validator.ValidationErrors вместе с ошибками тегов.
+func registerSubscriptionRules(v *validator.Validate, rules ValidationRules) {
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		c := sl.Current().Interface().(subscriptionCandidate)
+
+		start, err := validation.ParseMonthYear(c.StartDate)
+		if err != nil {
+			return // формат уже проверен тегом month_year
+		}
+
+		now := time.Now()
+		minYear := now.Year() - rules.MaxPastYears
+		maxYear := now.Year() + rules.MaxFutureYears
+		if start.Year() < minYear || start.Year() > maxYear {
+			sl.ReportError(c.StartDate, "start_date", "StartDate", "year_range", fmt.Sprintf("%d-%d", minYear, maxYear))
+		}
+
+		if c.EndDate != nil {
+			end, err := validation.ParseMonthYear(*c.EndDate)
+			if err == nil && end.Before(start) {
+				sl.ReportError(*c.EndDate, "end_date", "EndDate", "not_before_start", "start_date")
+			}
+		}
+
+		if c.previousUserID != "" && c.UserID != c.previousUserID && !rules.AllowUserIDChange {
+			sl.ReportError(c.UserID, "user_id", "UserID", "immutable", "")
+		}
+	}, subscriptionCandidate{})
+}
+
+// priceChangeCandidate изменение цены вместе с периодом подписки, к которой оно относится.
+type priceChangeCandidate struct {
+	models.SubscriptionPriceInput
+	sub models.Subscription
+}
+
+// registerPriceRules добавляет правило: изменение цены попадает внутрь периода подписки и не совпадает с её началом
+// (цену с start_date задаёт сама подписка).
+func registerPriceRules(v *validator.Validate) {
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		c := sl.Current().Interface().(priceChangeCandidate)
+
+		month, err := validation.ParseMonthYear(c.EffectiveFrom)
+		if err != nil {
+			return // формат уже проверен тегом month_year
+		}
+
+		start, err := validation.ParseMonthYear(c.sub.StartDate)
+		if err == nil && !month.After(start) {
+			sl.ReportError(c.EffectiveFrom, "effective_from", "EffectiveFrom", "after_start", "start_date")
+		}
+		if c.sub.EndDate != nil {
+			end, err := validation.ParseMonthYear(*c.sub.EndDate)
+			if err == nil && month.After(end) {
+				sl.ReportError(c.EffectiveFrom, "effective_from", "EffectiveFrom", "not_after_end", "end_date")
+			}
+		}
+	}, priceChangeCandidate{})
+}
+
+// newCandidate собирает подписку из входных данных создания.
+func newCandidate(input models.CreateSubscriptionInput) subscriptionCandidate {
+	sub := models.Subscription{
+		ServiceName:     input.ServiceName,
+		Price:           input.Price,
+		UserID:          input.UserID,
+		StartDate:       input.StartDate,
+		BillingPeriod:   input.BillingPeriod,
+		BillingInterval: input.BillingInterval,
+		Currency:        input.Currency,
+		Category:        input.Category,
+		Tags:            input.Tags,
+	}
+	if input.EndDate != "" {
+		sub.EndDate = &input.EndDate
+	}
+	return subscriptionCandidate{Subscription: sub}
+}
+
+// withInputDefaults по умолчанию подписка оплачивается ежемесячно в рублях.
+// Категория и теги приводятся к нижнему регистру.
+func withInputDefaults(input models.CreateSubscriptionInput) models.CreateSubscriptionInput {
+	input.Category = models.NormalizeLabel(input.Category)
+	input.Tags = models.NormalizeTags(input.Tags)
+	if input.BillingPeriod == "" {
+		input.BillingPeriod = models.BillingPeriodMonth
+	}
+	if input.BillingInterval == 0 {
+		input.BillingInterval = 1
+	}
+	if input.Currency == "" {
+		input.Currency = models.BaseCurrency
+	}
+	return input
+}
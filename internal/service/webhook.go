@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	// webhookTimeout сколько ждать ответа на отправку предупреждения
+	webhookTimeout = 5 * time.Second
+	// maxPendingWebhooks сколько предупреждений может отправляться одновременно; остальные не отправляются
+	maxPendingWebhooks = 32
+)
+
+var ErrWebhookNotAllowed = errors.New("webhook_url must point to a public address")
+
+// nonPublicPrefixes диапазоны, которые не считаются публичными помимо частных, loopback и link-local:
+// "этот" сеть, общий адрес провайдера (CGNAT) и сеть для тестов производительности.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// publicAddr проверяет, что адрес доступен из интернета: webhook не должен обращаться
+// к внутренним сервисам и метаданным облака (169.254.169.254).
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkWebhookURL проверяет при сохранении, что все адреса хоста webhook публичные.
+// При отправке адрес проверяется ещё раз: DNS мог измениться.
+func checkWebhookURL(ctx context.Context, rawURL string, allow func(netip.Addr) bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookNotAllowed, err)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s", ErrWebhookNotAllowed, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !allow(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookNotAllowed, u.Hostname(), addr)
+		}
+	}
+	return nil
+}
+
+// newWebhookClient HTTP-клиент для webhook: адрес проверяется при каждом подключении,
+// перенаправления не выполняются, прокси из окружения не используется — иначе проверялся бы адрес прокси.
+func newWebhookClient(allow func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allow(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookNotAllowed, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   webhookTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"em-internship/internal/models"
+	"em-internship/internal/repository"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":                true,
+		"2a00:1450:4010::65":     true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"::1":                    false,
+		"fe80::1":                false,
+		"fd00::1":                false,
+		"::ffff:169.254.169.254": false,
+	}
+	for addr, want := range tests {
+		if got := publicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestBudgetSave_RejectsInternalWebhook(t *testing.T) {
+	subRepo := repository.NewMemorySubscriptionRepository(zap.NewNop())
+	budgets := NewBudgetService(repository.NewMemoryBudgetRepository(), subRepo, repository.NewMemoryExchangeRateRepository(), zap.NewNop())
+
+	for _, url := range []string{"http://169.254.169.254/latest/meta-data", "http://localhost:8080/hook", "http://10.0.0.1/hook", "http://[::1]/hook"} {
+		_, err := budgets.Save(context.Background(), testUserID, models.BudgetInput{Amount: 100000, WebhookURL: url})
+		if !errors.Is(err, ErrWebhookNotAllowed) {
+			t.Errorf("Save(%s) error = %v, want ErrWebhookNotAllowed", url, err)
+		}
+	}
+}
+
+func TestWebhookClient(t *testing.T) {
+	hits := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer internal.Close()
+
+	// адрес проверяется при подключении, даже если при сохранении он был публичным
+	if _, err := newWebhookClient(publicAddr).Get(internal.URL); !errors.Is(err, ErrWebhookNotAllowed) {
+		t.Errorf("dial error = %v, want ErrWebhookNotAllowed", err)
+	}
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	resp, err := newWebhookClient(func(netip.Addr) bool { return true }).Post(redirect.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect || hits != 0 {
+		t.Errorf("status = %d, hits = %d: redirect must not be followed", resp.StatusCode, hits)
+	}
+}
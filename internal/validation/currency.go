@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// nonCentCurrencies коды ISO 4217, у которых минимальная единица — не сотая доля: без дробной части
+// (JPY, KRW, ...), с тремя и четырьмя знаками (KWD, CLF, ...), а также металлы и расчётные единицы.
+var nonCentCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true, "KMF": true, "KRW": true,
+	"PYG": true, "RWF": true, "UGX": true, "UYI": true, "VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true, "OMR": true, "TND": true,
+	"CLF": true, "UYW": true,
+	"XAG": true, "XAU": true, "XBA": true, "XBB": true, "XBC": true, "XBD": true, "XDR": true,
+	"XPD": true, "XPT": true, "XSU": true, "XTS": true, "XUA": true, "XXX": true,
+}
+
+// CentCurrency проверяет, что у валюты две цифры после запятой: суммы хранятся в сотых долях (models.Money).
+func CentCurrency(fl validator.FieldLevel) bool {
+	return !nonCentCurrencies[fl.Field().String()]
+}
+
+// RegisterCurrency регистрирует тег "currency": код ISO 4217 валюты с двумя знаками после запятой.
+func RegisterCurrency(v *validator.Validate) error {
+	if err := v.RegisterValidation("cent_currency", CentCurrency); err != nil {
+		return err
+	}
+	v.RegisterAlias("currency", "iso4217,cent_currency")
+	return nil
+}
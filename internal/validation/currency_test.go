@@ -0,0 +1,33 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestRegisterCurrency(t *testing.T) {
+	v := validator.New()
+	if err := RegisterCurrency(v); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"RUB", true},
+		{"USD", true},
+		{"EUR", true},
+		{"JPY", false},
+		{"KWD", false},
+		{"XAU", false},
+		{"ABC", false},
+		{"rub", false},
+	}
+	for _, tt := range tests {
+		if got := v.Var(tt.code, "currency") == nil; got != tt.want {
+			t.Errorf("currency %q valid = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
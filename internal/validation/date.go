@@ -1,13 +1,22 @@
 package validation
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// monthYearLayout формат MM-YYYY для time.Parse и Format
+const monthYearLayout = "01-2006"
+
+var ErrInvalidMonthYear = errors.New("invalid date format: use MM-YYYY")
+
 // Формат даты подписки: MM-YYYY (месяц 01–12, год — 4 цифры).
 var monthYearRegex = regexp.MustCompile(`^(0[1-9]|1[0-2])-\d{4}$`)
 
@@ -36,6 +45,21 @@ func MonthYear(fl validator.FieldLevel) bool {
 	return month >= 1 && month <= 12
 }
 
+// FieldName возвращает имя поля для ошибок валидации: из тега json, затем query, иначе имя в Go.
+// Регистрируется через validator.RegisterTagNameFunc, чтобы клиент видел имена полей как в API.
+func FieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
 // RegisterMonthYear регистрирует кастомный тег "month_year" в валидаторе.
 func RegisterMonthYear(v *validator.Validate) error {
 	return v.RegisterValidation("month_year", MonthYear)
@@ -51,3 +75,31 @@ func IsValidMonthYear(s string) bool {
 		return month >= 1 && month <= 12
 	}()
 }
+
+// ParseMonthYear разбирает строку MM-YYYY в первое число месяца (UTC).
+func ParseMonthYear(s string) (time.Time, error) {
+	if !IsValidMonthYear(s) {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidMonthYear, s)
+	}
+	return time.Parse(monthYearLayout, s)
+}
+
+// isoMonthLayouts даты ISO 8601, которые NormalizeMonthYear приводит к MM-YYYY
+var isoMonthLayouts = []string{"2006-01", "2006-01-02"}
+
+// NormalizeMonthYear приводит YYYY-MM и YYYY-MM-DD к MM-YYYY (день отбрасывается);
+// остальные строки возвращаются без изменений, чтобы их отклонила проверка month_year.
+func NormalizeMonthYear(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range isoMonthLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return FormatMonthYear(t)
+		}
+	}
+	return s
+}
+
+// FormatMonthYear форматирует дату в MM-YYYY (день месяца отбрасывается).
+func FormatMonthYear(t time.Time) string {
+	return t.Format(monthYearLayout)
+}
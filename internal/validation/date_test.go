@@ -64,3 +64,37 @@ func newTestValidator(t *testing.T) *validator.Validate {
 	}
 	return v
 }
+
+func TestParseMonthYear(t *testing.T) {
+	got, err := ParseMonthYear("07-2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Year() != 2025 || got.Month() != 7 || got.Day() != 1 {
+		t.Errorf("ParseMonthYear(07-2025) = %v", got)
+	}
+
+	if _, err := ParseMonthYear("13-2025"); err == nil {
+		t.Error("expected error for 13-2025")
+	}
+
+	if s := FormatMonthYear(got); s != "07-2025" {
+		t.Errorf("FormatMonthYear() = %q, want 07-2025", s)
+	}
+}
+
+func TestNormalizeMonthYear(t *testing.T) {
+	tests := map[string]string{
+		"07-2025":     "07-2025",
+		"2025-07":     "07-2025",
+		" 2025-07-15": "07-2025",
+		"2025-13":     "2025-13",
+		"07/2025":     "07/2025",
+		"":            "",
+	}
+	for in, want := range tests {
+		if got := NormalizeMonthYear(in); got != want {
+			t.Errorf("NormalizeMonthYear(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var decimalRegex = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
+// PositiveDecimal проверяет, что строка — положительное десятичное число без знака и экспоненты, например "92.5".
+// Такие значения не проходят через float и не теряют точность.
+func PositiveDecimal(fl validator.FieldLevel) bool {
+	return IsPositiveDecimal(fl.Field().String())
+}
+
+// IsPositiveDecimal возвращает true, если s — десятичное число больше нуля.
+func IsPositiveDecimal(s string) bool {
+	if !decimalRegex.MatchString(s) {
+		return false
+	}
+	return strings.Trim(s, "0.") != ""
+}
+
+// RegisterPositiveDecimal регистрирует кастомный тег "positive_decimal" в валидаторе.
+func RegisterPositiveDecimal(v *validator.Validate) error {
+	return v.RegisterValidation("positive_decimal", PositiveDecimal)
+}
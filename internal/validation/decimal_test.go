@@ -0,0 +1,26 @@
+package validation
+
+import "testing"
+
+func TestIsPositiveDecimal(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"92.5", true},
+		{"1", true},
+		{"0.0001", true},
+		{"0", false},
+		{"0.000", false},
+		{"-1", false},
+		{"1e3", false},
+		{".5", false},
+		{"5.", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsPositiveDecimal(tt.s); got != tt.want {
+			t.Errorf("IsPositiveDecimal(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
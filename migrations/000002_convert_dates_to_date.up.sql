@@ -0,0 +1,4 @@
+-- MM-YYYY -> DATE (первое число месяца), чтобы сравнения дат были хронологическими, а не лексикографическими
+ALTER TABLE subscriptions
+    ALTER COLUMN start_date TYPE DATE USING to_date(start_date, 'MM-YYYY'),
+    ALTER COLUMN end_date TYPE DATE USING to_date(end_date, 'MM-YYYY');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_subscriptions_price;
+DROP INDEX IF EXISTS idx_subscriptions_service_name_lower;
@@ -0,0 +1,3 @@
+-- поиск по префиксу названия без учёта регистра: lower(service_name) LIKE 'prefix%'
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_lower ON subscriptions (lower(service_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_price ON subscriptions (price);
@@ -0,0 +1,2 @@
+-- keyset-пагинация списка: ORDER BY created_at, id
+CREATE INDEX IF NOT EXISTS idx_subscriptions_created_at_id ON subscriptions (created_at, id);
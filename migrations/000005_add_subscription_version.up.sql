@@ -0,0 +1,2 @@
+-- версия для оптимистичной блокировки: увеличивается при каждом изменении, отдаётся клиенту как ETag
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+-- сохранённые ответы на запросы с заголовком Idempotency-Key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL, -- sha256 метода, пути и тела запроса
+    status_code INTEGER NOT NULL DEFAULT 0, -- 0 — запрос ещё выполняется
+    headers JSONB NOT NULL DEFAULT '{}',
+    body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,6 @@
+-- период оплаты: price списывается раз в billing_interval периодов billing_period
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period TEXT NOT NULL DEFAULT 'month'
+        CHECK (billing_period IN ('week', 'month', 'quarter', 'year')),
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1
+        CHECK (billing_interval BETWEEN 1 AND 52);
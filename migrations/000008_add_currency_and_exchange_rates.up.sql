@@ -0,0 +1,11 @@
+-- валюта цены подписки (ISO 4217); существующие цены — в рублях
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- курсы валют к базовой (RUB): курс действует с месяца effective_from до следующего курса этой валюты
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) NOT NULL,
+    effective_from DATE NOT NULL, -- первое число месяца
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (currency, effective_from)
+);
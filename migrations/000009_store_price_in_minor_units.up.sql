@@ -0,0 +1,2 @@
+-- цена в минимальных единицах валюты (копейках); BIGINT, чтобы суммы не упирались в INTEGER
+ALTER TABLE subscriptions ALTER COLUMN price TYPE BIGINT USING price::BIGINT * 100;
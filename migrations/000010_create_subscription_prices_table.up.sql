@@ -0,0 +1,9 @@
+-- изменения цены подписки: цена действует с месяца effective_from до следующего изменения,
+-- до первого изменения действует subscriptions.price
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL, -- первое число месяца
+    price BIGINT NOT NULL CHECK (price > 0), -- в копейках
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription_id, effective_from)
+);
@@ -0,0 +1,26 @@
+-- каталог сервисов, на которые оформляются подписки
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    default_price BIGINT CHECK (default_price > 0), -- в копейках, в валюте currency
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    website VARCHAR(2048) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- нормализованные название и синонимы сервиса (нижний регистр, одиночные пробелы);
+-- первичный ключ не даёт двум сервисам иметь одинаковое название или синоним
+CREATE TABLE IF NOT EXISTS service_aliases (
+    alias VARCHAR(255) PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_aliases_service_id ON service_aliases(service_id);
+
+-- сервис из каталога; у подписок, созданных до каталога или с неизвестным названием, пусто
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id UUID REFERENCES services(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions(service_id);
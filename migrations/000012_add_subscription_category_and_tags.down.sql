@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS subscription_tags;
+DROP INDEX IF EXISTS idx_subscriptions_category;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category;
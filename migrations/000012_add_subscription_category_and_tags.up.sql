@@ -0,0 +1,13 @@
+-- категория подписки: streaming, cloud, music и т.п.; пусто — без категории
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category);
+
+-- произвольные теги подписки в нижнем регистре
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    tag VARCHAR(50) NOT NULL,
+    PRIMARY KEY (subscription_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag ON subscription_tags(tag);
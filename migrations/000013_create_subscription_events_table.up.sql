@@ -0,0 +1,14 @@
+-- журнал изменений подписок; пишется в той же транзакции, что и изменение.
+-- Без внешнего ключа: история удалённой подписки сохраняется
+CREATE TABLE IF NOT EXISTS subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    event_type VARCHAR(20) NOT NULL,
+    version INT NOT NULL,
+    actor VARCHAR(255) NOT NULL DEFAULT '',
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    changes JSONB NOT NULL, -- {"поле": {"before": ..., "after": ...}}
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_events_subscription_id ON subscription_events(subscription_id, id);
@@ -0,0 +1,5 @@
+-- мягкое удаление: удалённые подписки не видны в списках и расчётах, их можно восстановить,
+-- пока они не удалены окончательно очисткой
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted_at ON subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
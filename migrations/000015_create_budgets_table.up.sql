@@ -0,0 +1,24 @@
+-- месячный бюджет пользователя на подписки
+CREATE TABLE IF NOT EXISTS budgets (
+    user_id UUID PRIMARY KEY,
+    amount BIGINT NOT NULL CHECK (amount > 0), -- в копейках, в валюте currency
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    webhook_url VARCHAR(2048) NOT NULL DEFAULT '',
+    exceeded BOOLEAN NOT NULL DEFAULT FALSE, -- расходы превышали бюджет при последней проверке
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- предупреждения о превышении бюджета. Без внешнего ключа: остаются после удаления бюджета
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    month DATE NOT NULL, -- первое число месяца, за который посчитаны расходы
+    spend BIGINT NOT NULL,
+    amount BIGINT NOT NULL, -- бюджет на момент превышения
+    currency CHAR(3) NOT NULL,
+    subscription_id UUID, -- изменение, после которого бюджет превышен
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_budget_alerts_user_id ON budget_alerts(user_id, id);
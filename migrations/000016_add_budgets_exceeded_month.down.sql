@@ -0,0 +1,3 @@
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS exceeded BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE budgets SET exceeded = TRUE WHERE exceeded_month = date_trunc('month', CURRENT_DATE)::date;
+ALTER TABLE budgets DROP COLUMN IF EXISTS exceeded_month;
@@ -0,0 +1,7 @@
+-- признак превышения относится к месяцу: в новом месяце расходы считаются заново,
+-- и переход через бюджет должен снова создать предупреждение
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS exceeded_month DATE; -- первое число месяца, в котором бюджет превышен
+
+UPDATE budgets SET exceeded_month = date_trunc('month', CURRENT_DATE)::date WHERE exceeded;
+
+ALTER TABLE budgets DROP COLUMN IF EXISTS exceeded;